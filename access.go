@@ -0,0 +1,56 @@
+package tracelog
+
+import (
+	"net"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// AccessLog logs a standard access log entry for the request, tagging the span with the
+// corresponding HTTP attributes of the version set using WithSemconvVersion. The level is
+// derived from the status: Error for 5xx, Warn for 4xx and Info otherwise. The span in
+// the logger's `context.Context` is tagged, falling back to the span in the request's
+// context. The `httpRequest` field is added when WithCloudLoggingOutput is used.
+func (tl *TraceLogger) AccessLog(r *http.Request, status int, size int64, dur time.Duration) {
+	lvl := zapcore.InfoLevel
+
+	switch {
+	case status >= http.StatusInternalServerError:
+		lvl = zapcore.ErrorLevel
+	case status >= http.StatusBadRequest:
+		lvl = zapcore.WarnLevel
+	}
+
+	ctx := tl.ctx
+	if ctx == nil {
+		ctx = r.Context()
+	}
+
+	clientIP := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		clientIP = host
+	}
+
+	args := []interface{}{
+		zap.String("method", r.Method),
+		zap.String("path", r.URL.Path),
+		zap.Int("status", status),
+		zap.Int64("size", size),
+		zap.Duration("duration", dur),
+		zap.String("remoteAddr", r.RemoteAddr),
+		zap.String("userAgent", r.UserAgent()),
+	}
+
+	if tl.cloudLogging {
+		args = append(args, CloudLoggingHTTPRequest(r, status, size, dur))
+	}
+
+	for _, attr := range tl.accessAttributes(r, status, size, clientIP) {
+		args = append(args, attr)
+	}
+
+	tl.log(ctx, lvl, "HTTP request", args)
+}
@@ -0,0 +1,164 @@
+package tracelog
+
+import (
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// adaptiveState is shared by an adaptive level core and the cores derived from it using With.
+type adaptiveState struct {
+	level     zap.AtomicLevel
+	threshold float64
+	window    time.Duration
+	cooldown  time.Duration
+
+	mu      sync.Mutex
+	buckets [adaptiveBuckets]adaptiveBucket
+	until   time.Time
+}
+
+// adaptiveBuckets is the number of buckets the window is divided into when counting
+// errors, bounding the memory used regardless of the error rate.
+const adaptiveBuckets = 10
+
+// adaptiveBucket counts the errors recorded within one slot of the window.
+type adaptiveBucket struct {
+	slot  int64
+	count int
+}
+
+// adaptiveLevelCore lowers its level to Debug while the error rate is elevated.
+type adaptiveLevelCore struct {
+	zapcore.Core
+	state *adaptiveState
+}
+
+// NewAdaptiveLevelCore creates a `zapcore.Core` that writes entries at InfoLevel and above,
+// lowering the level to DebugLevel for the cooldown period once the rate of ErrorLevel
+// entries within the window exceeds errThreshold errors per second. Further errors
+// extend the cooldown. The underlying core should be enabled at DebugLevel. When
+// installed using WithAdaptiveLevel and the level is lowered, the
+// `adaptive_level_trigger` attribute is set on the span of the entry that triggered it.
+func NewAdaptiveLevelCore(underlying zapcore.Core, errThreshold float64, window, cooldown time.Duration) zapcore.Core {
+	return &adaptiveLevelCore{
+		Core: underlying,
+		state: &adaptiveState{
+			level:     zap.NewAtomicLevelAt(zapcore.InfoLevel),
+			threshold: errThreshold,
+			window:    window,
+			cooldown:  cooldown,
+		},
+	}
+}
+
+// WithAdaptiveLevel lowers the level of the base logger to DebugLevel while the rate of
+// ErrorLevel entries is elevated, as described by NewAdaptiveLevelCore. The base logger
+// built by the TraceLogger is enabled at DebugLevel unless WithLevelEnabler is provided.
+// Entries of contexts marked using ForceVerbose are not filtered.
+func WithAdaptiveLevel(errThreshold float64, window, cooldown time.Duration) LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl == nil {
+			return
+		}
+
+		tl.contextCores = true
+		tl.adaptiveLevel = func(core zapcore.Core) zapcore.Core {
+			return NewAdaptiveLevelCore(core, errThreshold, window, cooldown)
+		}
+	}
+}
+
+func (c *adaptiveLevelCore) Enabled(lvl zapcore.Level) bool {
+	return c.state.level.Enabled(lvl) && c.Core.Enabled(lvl)
+}
+
+func (c *adaptiveLevelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &adaptiveLevelCore{
+		Core:  c.Core.With(fields),
+		state: c.state,
+	}
+}
+
+func (c *adaptiveLevelCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	c.state.expire(time.Now())
+
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+
+	return ce
+}
+
+func (c *adaptiveLevelCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if ent.Level >= zapcore.ErrorLevel && c.state.recordError(time.Now()) {
+		if ctx, ok := contextFromFields(fields); ok {
+			trace.SpanFromContext(ctx).SetAttributes(attribute.Bool("adaptive_level_trigger", true))
+		}
+	}
+
+	return c.Core.Write(ent, fields)
+}
+
+// recordError records an error at now, reporting whether the level was lowered as a result.
+// Errors are counted in buckets each covering a fraction of the window, so the rate
+// is approximate to within one bucket.
+func (s *adaptiveState) recordError(now time.Time) bool {
+	if s.window <= 0 {
+		return false
+	}
+
+	width := int64(s.window) / adaptiveBuckets
+	if width < 1 {
+		width = 1
+	}
+
+	slot := now.UnixNano() / width
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b := &s.buckets[slot%adaptiveBuckets]
+	if b.slot != slot {
+		b.slot, b.count = slot, 0
+	}
+	b.count++
+
+	total := 0
+	for _, b := range s.buckets {
+		if b.slot > slot-adaptiveBuckets {
+			total += b.count
+		}
+	}
+
+	if float64(total)/s.window.Seconds() <= s.threshold {
+		return false
+	}
+
+	s.until = now.Add(s.cooldown)
+	if s.level.Level() == zapcore.DebugLevel {
+		return false
+	}
+
+	s.level.SetLevel(zapcore.DebugLevel)
+
+	return true
+}
+
+// expire restores the level once the cooldown has elapsed.
+func (s *adaptiveState) expire(now time.Time) {
+	if s.level.Level() != zapcore.DebugLevel {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.After(s.until) {
+		s.level.SetLevel(zapcore.InfoLevel)
+	}
+}
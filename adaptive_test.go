@@ -0,0 +1,72 @@
+package tracelog
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestAdaptiveStateCountsErrorsWithinWindow(t *testing.T) {
+	core := NewAdaptiveLevelCore(zapcore.NewNopCore(), 2, time.Second, time.Minute).(*adaptiveLevelCore)
+	s := core.state
+
+	start := time.Unix(100, 0)
+	for i := 0; i < 2; i++ {
+		if s.recordError(start.Add(time.Duration(i) * 100 * time.Millisecond)) {
+			t.Fatalf("level lowered after %d errors", i+1)
+		}
+	}
+
+	// The earlier errors have left the window, so the rate has not been exceeded.
+	later := start.Add(5 * time.Second)
+	for i := 0; i < 2; i++ {
+		if s.recordError(later.Add(time.Duration(i) * 100 * time.Millisecond)) {
+			t.Fatalf("level lowered counting errors outside the window")
+		}
+	}
+
+	if !s.recordError(later.Add(300 * time.Millisecond)) {
+		t.Fatal("level not lowered once the rate exceeded the threshold")
+	}
+
+	if got := s.level.Level(); got != zapcore.DebugLevel {
+		t.Errorf("level = %s, want %s", got, zapcore.DebugLevel)
+	}
+}
+
+func TestWithAdaptiveLevelTagsTriggeringSpan(t *testing.T) {
+	rec := useSpanRecorder(t)
+	tl, buf := newBufferedLogger(WithAdaptiveLevel(0, time.Second, time.Minute))
+
+	lg, span := tl.SetContext(context.Background()).StartSpan("work")
+	lg.Debug("before")
+	lg.Error("failed")
+	lg.Debug("after")
+	span.End()
+
+	var msgs []interface{}
+	for _, entry := range buf.Entries(t) {
+		msgs = append(msgs, entry["msg"])
+	}
+
+	if len(msgs) != 3 || msgs[0] != "failed" || msgs[1] != "after" {
+		t.Errorf("messages = %v, want [failed after span completed]", msgs)
+	}
+
+	spans := rec.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+
+	want := attribute.Bool("adaptive_level_trigger", true)
+	for _, attr := range spans[0].Attributes() {
+		if attr == want {
+			return
+		}
+	}
+
+	t.Errorf("span attributes %v do not contain %v", spans[0].Attributes(), want)
+}
@@ -0,0 +1,83 @@
+package tracelog
+
+import (
+	"context"
+	"reflect"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// structTagKey is the struct tag naming the attribute a field is set as.
+const structTagKey = "tracelog"
+
+// AnnotateSpanFromStruct sets attributes on the span in ctx from the exported fields of
+// the struct v, or the struct v points to, which are tagged with `tracelog:"name"`. The
+// prefix is prepended to each name. Fields of type string, bool, float64, the integer
+// types, []string and []int64 are supported, and other fields are skipped, as are
+// fields tagged `tracelog:"-"`. It does nothing when the span is not recording.
+func AnnotateSpanFromStruct(ctx context.Context, prefix string, v interface{}) {
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return
+		}
+
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return
+	}
+
+	rt := rv.Type()
+
+	var attrs []attribute.KeyValue
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+
+		name, ok := sf.Tag.Lookup(structTagKey)
+		if !ok || name == "" || name == "-" || sf.PkgPath != "" {
+			continue
+		}
+
+		if attr, ok := structFieldAttribute(attribute.Key(prefix+name), rv.Field(i)); ok {
+			attrs = append(attrs, attr)
+		}
+	}
+
+	if len(attrs) > 0 {
+		span.SetAttributes(attrs...)
+	}
+}
+
+// structFieldAttribute converts a struct field into an attribute, reporting false for
+// unsupported types.
+func structFieldAttribute(key attribute.Key, fv reflect.Value) (attribute.KeyValue, bool) {
+	switch fv.Kind() {
+	case reflect.String:
+		return key.String(fv.String()), true
+	case reflect.Bool:
+		return key.Bool(fv.Bool()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return key.Int64(fv.Int()), true
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return key.Int64(int64(fv.Uint())), true
+	case reflect.Float32, reflect.Float64:
+		return key.Float64(fv.Float()), true
+	case reflect.Slice:
+		switch s := fv.Interface().(type) {
+		case []string:
+			return key.StringSlice(s), true
+		case []int64:
+			return key.Int64Slice(s), true
+		}
+	}
+
+	return attribute.KeyValue{}, false
+}
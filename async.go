@@ -0,0 +1,258 @@
+package tracelog
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// DropPolicy determines how the asynchronous WriteSyncer behaves when its buffer is full.
+type DropPolicy int
+
+const (
+	// Block waits for space in the buffer, applying backpressure to the caller.
+	Block DropPolicy = iota
+	// DropNewest discards the entry being written.
+	DropNewest
+	// DropOldest discards the oldest buffered entry to make room for the entry being written.
+	DropOldest
+)
+
+type asyncConfig struct {
+	policy       DropPolicy
+	onDrop       func([]byte)
+	flushTimeout time.Duration
+}
+
+// AsyncOption configures the asynchronous WriteSyncer.
+type AsyncOption func(*asyncConfig)
+
+// WithDropPolicy sets the behavior when the buffer is full. Defaults to Block.
+func WithDropPolicy(policy DropPolicy) AsyncOption {
+	return func(cfg *asyncConfig) {
+		if cfg != nil {
+			cfg.policy = policy
+		}
+	}
+}
+
+// WithOnDrop sets a function called with each entry discarded by the drop policy.
+func WithOnDrop(fn func([]byte)) AsyncOption {
+	return func(cfg *asyncConfig) {
+		if cfg != nil {
+			cfg.onDrop = fn
+		}
+	}
+}
+
+// WithFlushTimeout bounds how long Sync waits for the buffer to drain. By default
+// Sync waits until the buffer is empty.
+func WithFlushTimeout(d time.Duration) AsyncOption {
+	return func(cfg *asyncConfig) {
+		if cfg != nil {
+			cfg.flushTimeout = d
+		}
+	}
+}
+
+// asyncWriteSyncer buffers entries in a channel that is drained to the underlying
+// WriteSyncer by a background goroutine.
+type asyncWriteSyncer struct {
+	underlying zapcore.WriteSyncer
+	cfg        *asyncConfig
+
+	queue   chan []byte
+	flush   chan chan error
+	done    chan struct{}
+	stopped chan struct{}
+	once    sync.Once
+
+	// closeMu is held for reading while entries are buffered, so Close only stops the
+	// background goroutine once in flight writes are buffered to be drained.
+	closeMu sync.RWMutex
+	closed  bool
+
+	mu      sync.Mutex
+	lastErr error
+}
+
+// NewAsyncWriteSyncer creates a `zapcore.WriteSyncer` that decouples writing entries from
+// the underlying WriteSyncer using a buffer of bufferSize entries. Sync drains the buffer
+// before syncing the underlying WriteSyncer. The returned WriteSyncer implements `io.Closer`.
+func NewAsyncWriteSyncer(underlying zapcore.WriteSyncer, bufferSize int, opts ...AsyncOption) zapcore.WriteSyncer {
+	cfg := &asyncConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	if bufferSize < 1 {
+		bufferSize = 1
+	}
+
+	ws := &asyncWriteSyncer{
+		underlying: underlying,
+		cfg:        cfg,
+		queue:      make(chan []byte, bufferSize),
+		flush:      make(chan chan error),
+		done:       make(chan struct{}),
+		stopped:    make(chan struct{}),
+	}
+
+	go ws.run()
+
+	return ws
+}
+
+// WithAsyncOutput buffers up to bufferSize entries, writing them to the output in the
+// background. See NewAsyncWriteSyncer. Use Close to write the buffered entries and stop
+// the background goroutine once the logger is no longer needed.
+func WithAsyncOutput(bufferSize int) LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl != nil {
+			tl.asyncBufferSize = bufferSize
+		}
+	}
+}
+
+func (ws *asyncWriteSyncer) run() {
+	defer close(ws.stopped)
+
+	for {
+		select {
+		case b := <-ws.queue:
+			ws.write(b)
+		case reply := <-ws.flush:
+			ws.drain()
+			reply <- ws.underlying.Sync()
+		case <-ws.done:
+			ws.drain()
+
+			return
+		}
+	}
+}
+
+// drain writes all currently buffered entries.
+func (ws *asyncWriteSyncer) drain() {
+	for {
+		select {
+		case b := <-ws.queue:
+			ws.write(b)
+		default:
+			return
+		}
+	}
+}
+
+func (ws *asyncWriteSyncer) write(b []byte) {
+	if _, err := ws.underlying.Write(b); err != nil {
+		ws.mu.Lock()
+		ws.lastErr = err
+		ws.mu.Unlock()
+	}
+}
+
+// Write buffers a copy of p according to the drop policy. Writes fail once closed.
+func (ws *asyncWriteSyncer) Write(p []byte) (int, error) {
+	ws.closeMu.RLock()
+	defer ws.closeMu.RUnlock()
+
+	if ws.closed {
+		return 0, errors.New("async write syncer is closed")
+	}
+
+	b := make([]byte, len(p))
+	copy(b, p)
+
+	switch ws.cfg.policy {
+	case DropNewest:
+		select {
+		case ws.queue <- b:
+		default:
+			ws.dropped(b)
+		}
+	case DropOldest:
+		for {
+			select {
+			case ws.queue <- b:
+				return len(p), nil
+			default:
+			}
+
+			select {
+			case old := <-ws.queue:
+				ws.dropped(old)
+			default:
+			}
+		}
+	default:
+		ws.queue <- b
+	}
+
+	return len(p), nil
+}
+
+func (ws *asyncWriteSyncer) dropped(b []byte) {
+	if ws.cfg.onDrop != nil {
+		ws.cfg.onDrop(b)
+	}
+}
+
+// Sync drains the buffer and syncs the underlying WriteSyncer, returning the last
+// error encountered writing buffered entries.
+func (ws *asyncWriteSyncer) Sync() error {
+	reply := make(chan error, 1)
+
+	var timeout <-chan time.Time
+	if ws.cfg.flushTimeout > 0 {
+		timer := time.NewTimer(ws.cfg.flushTimeout)
+		defer timer.Stop()
+
+		timeout = timer.C
+	}
+
+	select {
+	case ws.flush <- reply:
+	case <-ws.done:
+		return nil
+	case <-timeout:
+		return errors.New("timed out flushing async write syncer")
+	}
+
+	var err error
+	select {
+	case err = <-reply:
+	case <-timeout:
+		return errors.New("timed out flushing async write syncer")
+	}
+
+	ws.mu.Lock()
+	lastErr := ws.lastErr
+	ws.lastErr = nil
+	ws.mu.Unlock()
+
+	if lastErr != nil {
+		return fmt.Errorf("failed to write buffered entry: %w", lastErr)
+	}
+
+	return err
+}
+
+// Close drains the buffer and stops the background goroutine. Writes blocked waiting
+// for space in the buffer are written before it stops.
+func (ws *asyncWriteSyncer) Close() error {
+	ws.closeMu.Lock()
+	ws.closed = true
+	ws.closeMu.Unlock()
+
+	ws.once.Do(func() {
+		close(ws.done)
+	})
+
+	<-ws.stopped
+
+	return ws.underlying.Sync()
+}
@@ -0,0 +1,233 @@
+package tracelog
+
+import (
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestCloseStopsAsyncOutput(t *testing.T) {
+	tl, buf := newBufferedLogger(WithAsyncOutput(8))
+
+	tl.Info("buffered")
+
+	if err := tl.Close(); err != nil {
+		t.Fatalf("failed to close: %v", err)
+	}
+
+	if n := len(buf.Lines()); n != 1 {
+		t.Errorf("got %d entries, want the buffered entry written on Close", n)
+	}
+
+	if len(tl.closers) != 1 {
+		t.Fatalf("got %d closers, want the async output", len(tl.closers))
+	}
+
+	select {
+	case <-tl.closers[0].(*asyncWriteSyncer).stopped:
+	default:
+		t.Error("async output still running once closed")
+	}
+}
+
+// blockingSyncer blocks writes until released, signalling each write it receives.
+type blockingSyncer struct {
+	syncBuffer
+	entered chan struct{}
+	release chan struct{}
+}
+
+func newBlockingSyncer() *blockingSyncer {
+	return &blockingSyncer{
+		entered: make(chan struct{}, 16),
+		release: make(chan struct{}),
+	}
+}
+
+func (s *blockingSyncer) Write(p []byte) (int, error) {
+	s.entered <- struct{}{}
+	<-s.release
+
+	return s.syncBuffer.Write(p)
+}
+
+// fillAsyncBuffer writes first, waiting until the background goroutine is blocked
+// writing it, then writes second to fill the buffer of one entry.
+func fillAsyncBuffer(t *testing.T, ws zapcore.WriteSyncer, out *blockingSyncer, first, second string) {
+	t.Helper()
+
+	if _, err := ws.Write([]byte(first)); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+
+	<-out.entered
+
+	if _, err := ws.Write([]byte(second)); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+}
+
+func TestAsyncWriteSyncerDropPolicies(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      DropPolicy
+		wantDropped string
+		wantWritten string
+	}{
+		{name: "DropNewest", policy: DropNewest, wantDropped: "c", wantWritten: "ab"},
+		{name: "DropOldest", policy: DropOldest, wantDropped: "b", wantWritten: "ac"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			out := newBlockingSyncer()
+
+			var dropped []string
+			ws := NewAsyncWriteSyncer(out, 1, WithDropPolicy(tt.policy), WithOnDrop(func(b []byte) {
+				dropped = append(dropped, string(b))
+			}))
+
+			fillAsyncBuffer(t, ws, out, "a", "b")
+
+			if _, err := ws.Write([]byte("c")); err != nil {
+				t.Fatalf("failed to write: %v", err)
+			}
+
+			if strings.Join(dropped, "") != tt.wantDropped {
+				t.Errorf("dropped %q, want %q", dropped, tt.wantDropped)
+			}
+
+			close(out.release)
+
+			if err := ws.(io.Closer).Close(); err != nil {
+				t.Fatalf("failed to close: %v", err)
+			}
+
+			if got := out.String(); got != tt.wantWritten {
+				t.Errorf("wrote %q, want %q", got, tt.wantWritten)
+			}
+		})
+	}
+}
+
+func TestAsyncWriteSyncerBlockWritesEveryEntry(t *testing.T) {
+	out := newBlockingSyncer()
+	ws := NewAsyncWriteSyncer(out, 1)
+
+	fillAsyncBuffer(t, ws, out, "a", "b")
+
+	written := make(chan error, 1)
+	go func() {
+		_, err := ws.Write([]byte("c"))
+		written <- err
+	}()
+
+	select {
+	case <-written:
+		t.Fatal("write did not block on the full buffer")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(out.release)
+
+	if err := <-written; err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+
+	if err := ws.(io.Closer).Close(); err != nil {
+		t.Fatalf("failed to close: %v", err)
+	}
+
+	if got := out.String(); got != "abc" {
+		t.Errorf("wrote %q, want every entry", got)
+	}
+}
+
+func TestAsyncWriteSyncerDoesNotLoseEntriesWrittenDuringClose(t *testing.T) {
+	out := &syncBuffer{}
+	ws := NewAsyncWriteSyncer(out, 1)
+
+	var (
+		wg       sync.WaitGroup
+		accepted atomic.Int32
+	)
+
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 100; j++ {
+				if _, err := ws.Write([]byte("x")); err == nil {
+					accepted.Add(1)
+				}
+			}
+		}()
+	}
+
+	if err := ws.(io.Closer).Close(); err != nil {
+		t.Fatalf("failed to close: %v", err)
+	}
+
+	wg.Wait()
+
+	if got, want := len(out.String()), int(accepted.Load()); got != want {
+		t.Errorf("wrote %d entries, want the %d accepted", got, want)
+	}
+}
+
+func TestAsyncWriteSyncerRejectsWritesOnceClosed(t *testing.T) {
+	for name, policy := range map[string]DropPolicy{"Block": Block, "DropNewest": DropNewest, "DropOldest": DropOldest} {
+		policy := policy
+
+		t.Run(name, func(t *testing.T) {
+			out := &syncBuffer{}
+
+			var dropped int
+			ws := NewAsyncWriteSyncer(out, 1, WithDropPolicy(policy), WithOnDrop(func([]byte) {
+				dropped++
+			}))
+
+			if err := ws.(io.Closer).Close(); err != nil {
+				t.Fatalf("failed to close: %v", err)
+			}
+
+			if _, err := ws.Write([]byte("late")); err == nil {
+				t.Error("expected an error writing once closed")
+			}
+
+			if out.String() != "" || dropped != 0 {
+				t.Errorf("late entry was written or dropped: %q, %d drops", out.String(), dropped)
+			}
+		})
+	}
+}
+
+func TestAsyncWriteSyncerFlushTimeout(t *testing.T) {
+	out := newBlockingSyncer()
+	ws := NewAsyncWriteSyncer(out, 1, WithFlushTimeout(50*time.Millisecond))
+
+	if _, err := ws.Write([]byte("a")); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+
+	<-out.entered
+
+	if err := ws.Sync(); err == nil || !strings.Contains(err.Error(), "timed out") {
+		t.Errorf("Sync error = %v, want a timeout", err)
+	}
+
+	close(out.release)
+
+	if err := ws.(io.Closer).Close(); err != nil {
+		t.Fatalf("failed to close: %v", err)
+	}
+}
@@ -0,0 +1,151 @@
+package tracelog
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// WithAttributeValidation drops invalid attributes, such as those without a key, instead
+// of tagging the span with them, logging a Warn entry for each dropped attribute.
+func WithAttributeValidation() LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl != nil {
+			tl.validateAttrs = true
+			tl.invalidAttrLevel = zapcore.WarnLevel
+		}
+	}
+}
+
+// WithStrictAttributeValidation drops invalid attributes like WithAttributeValidation,
+// but logs the dropped attributes at DPanicLevel so the logger panics in development.
+func WithStrictAttributeValidation() LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl != nil {
+			tl.validateAttrs = true
+			tl.invalidAttrLevel = zapcore.DPanicLevel
+		}
+	}
+}
+
+// AttrsToFields converts OpenTelemetry attributes into the equivalent zap fields, so a
+// single set of attributes can be both logged and used to tag a span. Invalid
+// attributes are skipped.
+func AttrsToFields(attrs ...attribute.KeyValue) []zap.Field {
+	fields := make([]zap.Field, 0, len(attrs))
+
+	for _, attr := range attrs {
+		key := string(attr.Key)
+
+		switch attr.Value.Type() {
+		case attribute.BOOL:
+			fields = append(fields, zap.Bool(key, attr.Value.AsBool()))
+		case attribute.INT64:
+			fields = append(fields, zap.Int64(key, attr.Value.AsInt64()))
+		case attribute.FLOAT64:
+			fields = append(fields, zap.Float64(key, attr.Value.AsFloat64()))
+		case attribute.STRING:
+			fields = append(fields, zap.String(key, attr.Value.AsString()))
+		case attribute.BOOLSLICE:
+			fields = append(fields, zap.Bools(key, attr.Value.AsBoolSlice()))
+		case attribute.INT64SLICE:
+			fields = append(fields, zap.Int64s(key, attr.Value.AsInt64Slice()))
+		case attribute.FLOAT64SLICE:
+			fields = append(fields, zap.Float64s(key, attr.Value.AsFloat64Slice()))
+		case attribute.STRINGSLICE:
+			fields = append(fields, zap.Strings(key, attr.Value.AsStringSlice()))
+		case attribute.INVALID:
+		}
+	}
+
+	return fields
+}
+
+// FieldsToAttrs converts zap fields into the equivalent OpenTelemetry attributes, so a
+// single set of fields can be both logged and used to tag a span. Fields of types without
+// an attribute equivalent are converted to strings, and namespaces are skipped.
+func FieldsToAttrs(fields ...zap.Field) []attribute.KeyValue {
+	enc := zapcore.NewMapObjectEncoder()
+	attrs := make([]attribute.KeyValue, 0, len(fields))
+
+	for _, f := range fields {
+		if f.Type == zapcore.SkipType || f.Type == zapcore.NamespaceType {
+			continue
+		}
+
+		f.AddTo(enc)
+
+		val, ok := enc.Fields[f.Key]
+		if !ok {
+			continue
+		}
+
+		delete(enc.Fields, f.Key)
+
+		key := attribute.Key(f.Key)
+
+		switch v := val.(type) {
+		case string:
+			attrs = append(attrs, key.String(v))
+		case bool:
+			attrs = append(attrs, key.Bool(v))
+		case int:
+			attrs = append(attrs, key.Int(v))
+		case int64:
+			attrs = append(attrs, key.Int64(v))
+		case int32:
+			attrs = append(attrs, key.Int64(int64(v)))
+		case int16:
+			attrs = append(attrs, key.Int64(int64(v)))
+		case int8:
+			attrs = append(attrs, key.Int64(int64(v)))
+		case uint32:
+			attrs = append(attrs, key.Int64(int64(v)))
+		case uint16:
+			attrs = append(attrs, key.Int64(int64(v)))
+		case uint8:
+			attrs = append(attrs, key.Int64(int64(v)))
+		case float64:
+			attrs = append(attrs, key.Float64(v))
+		case float32:
+			attrs = append(attrs, key.Float64(float64(v)))
+		default:
+			attrs = append(attrs, key.String(fmt.Sprint(v)))
+		}
+	}
+
+	return attrs
+}
+
+// validAttributes returns the valid attributes, logging each invalid attribute at the
+// configured level. It is called by log, so skips an additional caller.
+func (tl *TraceLogger) validAttributes(ctx context.Context, attrs []attribute.KeyValue) []attribute.KeyValue {
+	valid := attrs[:0]
+	for _, attr := range attrs {
+		if attr.Valid() {
+			valid = append(valid, attr)
+			continue
+		}
+
+		ce := tl.base.WithOptions(zap.AddCallerSkip(1)).Check(tl.invalidAttrLevel, "dropped invalid span attribute")
+		if ce == nil {
+			continue
+		}
+
+		fields := []zap.Field{
+			zap.String("attribute", string(attr.Key)),
+			zap.String("type", attr.Value.Type().String()),
+		}
+
+		if tl.contextCores && ctx != nil {
+			fields = append(fields, contextField(ctx))
+		}
+
+		ce.Write(fields...)
+	}
+
+	return valid
+}
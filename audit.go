@@ -0,0 +1,136 @@
+package tracelog
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// WithAuditLogger additionally writes entries at or above minLevel to the provided
+// audit logger, including the correlation fields. The primary logger is always
+// written first, so a failure writing to the audit logger does not prevent the
+// primary write.
+func WithAuditLogger(lg *zap.Logger, minLevel zapcore.Level) LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl == nil || lg == nil {
+			return
+		}
+
+		audit := &levelFilterCore{
+			Core:         lg.Core(),
+			LevelEnabler: minLevel,
+		}
+
+		tl.zapOpts = append(tl.zapOpts, zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return zapcore.NewTee(core, audit)
+		}))
+	}
+}
+
+// levelFilterCore only passes entries enabled by its LevelEnabler to the wrapped core.
+type levelFilterCore struct {
+	zapcore.Core
+	zapcore.LevelEnabler
+}
+
+func (c *levelFilterCore) Enabled(lvl zapcore.Level) bool {
+	return c.LevelEnabler.Enabled(lvl) && c.Core.Enabled(lvl)
+}
+
+func (c *levelFilterCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelFilterCore{
+		Core:         c.Core.With(fields),
+		LevelEnabler: c.LevelEnabler,
+	}
+}
+
+func (c *levelFilterCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.LevelEnabler.Enabled(ent.Level) {
+		return ce
+	}
+
+	return c.Core.Check(ent, ce)
+}
+
+// An AuditLogger writes audit entries which must include a set of required fields, such
+// as `actor`, `action`, `resource` and `outcome`.
+type AuditLogger struct {
+	tl       *TraceLogger
+	required []string
+}
+
+// NewAuditLogger creates an AuditLogger writing to tl, requiring the fields with the
+// provided keys on every entry.
+func NewAuditLogger(tl *TraceLogger, required ...string) *AuditLogger {
+	return &AuditLogger{
+		tl:       tl.WithCallerSkip(1),
+		required: required,
+	}
+}
+
+// Log writes an audit entry at InfoLevel, stamped with the `audit` field and the time
+// it was logged as the ISO 8601 `timestamp` field. The stamp is added alongside the
+// correlation fields, so it is neither nested by WithFieldNamespace nor changed by
+// FieldTransformers. An error is returned, and nothing is logged, when any required
+// field is missing or InfoLevel entries are not enabled.
+func (a *AuditLogger) Log(msg string, fields ...zap.Field) error {
+	var missing []string
+
+	for _, key := range a.required {
+		found := false
+		for _, f := range fields {
+			if f.Key == key {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			missing = append(missing, key)
+		}
+	}
+
+	if len(missing) > 0 {
+		return fmt.Errorf("audit entry is missing required fields: %s", strings.Join(missing, ", "))
+	}
+
+	if !a.tl.Enabled(zapcore.InfoLevel) {
+		return fmt.Errorf("audit entry was not written: %s entries are not enabled", zapcore.InfoLevel)
+	}
+
+	args := make([]interface{}, 0, len(fields))
+	for _, f := range fields {
+		args = append(args, f)
+	}
+
+	a.tl.withCorrelation(zap.Inline(auditStamp{time: time.Now()})).Info(msg, args...)
+
+	return nil
+}
+
+// auditStamp encodes the `audit` and `timestamp` fields of an audit entry.
+type auditStamp struct {
+	time time.Time
+}
+
+func (s auditStamp) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddBool("audit", true)
+	enc.AddString("timestamp", s.time.UTC().Format(time.RFC3339Nano))
+
+	return nil
+}
+
+// withoutAuditStamp removes the stamp of an audit entry from fields, returning it along
+// with the remaining fields and whether it was found.
+func withoutAuditStamp(fields []zapcore.Field) (zapcore.Field, []zapcore.Field, bool) {
+	for i, f := range fields {
+		if _, ok := f.Interface.(auditStamp); ok {
+			return f, append(fields[:i], fields[i+1:]...), true
+		}
+	}
+
+	return zapcore.Field{}, fields, false
+}
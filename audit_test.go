@@ -0,0 +1,45 @@
+package tracelog
+
+import (
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestAuditStampUnaffectedByNamespaceAndTransformers(t *testing.T) {
+	tl, buf := newBufferedLogger(
+		WithFieldNamespace("data"),
+		WithFieldTransformers(RemoveField("audit"), RenameField("timestamp", "ts")),
+	)
+
+	if err := NewAuditLogger(tl, "actor").Log("deleted", zap.String("actor", "alice")); err != nil {
+		t.Fatalf("failed to log audit entry: %v", err)
+	}
+
+	entry := buf.Entries(t)[0]
+	if entry["audit"] != true {
+		t.Errorf("audit = %v, want a top-level true", entry["audit"])
+	}
+
+	if _, ok := entry["timestamp"].(string); !ok {
+		t.Errorf("timestamp = %v, want a top-level string", entry["timestamp"])
+	}
+
+	data, _ := entry["data"].(map[string]interface{})
+	if data["actor"] != "alice" {
+		t.Errorf("data = %v, want the actor nested in the namespace", entry["data"])
+	}
+}
+
+func TestAuditLoggerReturnsErrorWhenNotEnabled(t *testing.T) {
+	tl, buf := newBufferedLogger(WithLevelEnabler(zapcore.WarnLevel))
+
+	if err := NewAuditLogger(tl).Log("deleted"); err == nil {
+		t.Error("logged an audit entry below the enabled level without an error")
+	}
+
+	if n := len(buf.Entries(t)); n != 0 {
+		t.Errorf("got %d entries, want none", n)
+	}
+}
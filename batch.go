@@ -0,0 +1,227 @@
+package tracelog
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	defaultBatchSize    = 100
+	defaultBatchTimeout = time.Second
+)
+
+// batchQueueSize is the number of full batches queued for the background sender before
+// writes block.
+const batchQueueSize = 4
+
+// batchSyncer buffers entries written to it, handing full batches to a background
+// goroutine which calls send. The buffered entries are also sent once the batch timeout
+// elapses, or when Sync is called.
+type batchSyncer struct {
+	send    func(entries [][]byte) error
+	size    int
+	timeout time.Duration
+
+	mu      sync.Mutex
+	entries [][]byte
+
+	// err is the first error sending a batch in the background, returned by Sync.
+	err error
+
+	// closeMu guards queuing batches against Close, so every queued batch is sent.
+	closeMu sync.RWMutex
+	closed  bool
+	queue   chan batchRequest
+
+	once sync.Once
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// batchRequest is a batch queued for the background sender. When flushed is set, the
+// result of sending the batch is returned on it, once the batches queued before it have
+// been sent.
+type batchRequest struct {
+	entries [][]byte
+	flushed chan error
+}
+
+func newBatchSyncer(size int, timeout time.Duration, send func([][]byte) error) *batchSyncer {
+	if size <= 0 {
+		size = defaultBatchSize
+	}
+
+	if timeout <= 0 {
+		timeout = defaultBatchTimeout
+	}
+
+	b := &batchSyncer{
+		send:    send,
+		size:    size,
+		timeout: timeout,
+		queue:   make(chan batchRequest, batchQueueSize),
+		done:    make(chan struct{}),
+	}
+
+	b.wg.Add(1)
+
+	go b.run()
+
+	return b
+}
+
+func (b *batchSyncer) run() {
+	defer b.wg.Done()
+
+	ticker := time.NewTicker(b.timeout)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case req := <-b.queue:
+			b.process(req)
+		case <-ticker.C:
+			b.recordError(b.sendBatch(b.take()))
+		case <-b.done:
+			// No batches are queued once closed, so the queue can be drained.
+			for {
+				select {
+				case req := <-b.queue:
+					b.process(req)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// process sends a queued batch, returning the result to a waiting Sync.
+func (b *batchSyncer) process(req batchRequest) {
+	err := b.sendBatch(req.entries)
+	if req.flushed == nil {
+		b.recordError(err)
+
+		return
+	}
+
+	b.mu.Lock()
+	if err == nil {
+		err = b.err
+	}
+	b.err = nil
+	b.mu.Unlock()
+
+	req.flushed <- err
+}
+
+// Write buffers a copy of p, handing the batch to the background sender when it is full.
+func (b *batchSyncer) Write(p []byte) (int, error) {
+	entry := make([]byte, len(p))
+	copy(entry, p)
+
+	var full [][]byte
+
+	b.mu.Lock()
+	b.entries = append(b.entries, entry)
+	if len(b.entries) >= b.size {
+		full, b.entries = b.entries, nil
+	}
+	b.mu.Unlock()
+
+	if full != nil && !b.enqueue(batchRequest{entries: full}) {
+		if err := b.sendBatch(full); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(p), nil
+}
+
+// Sync sends any buffered entries, waiting for the batches queued before them, and
+// returns the first error sending a batch since the last Sync.
+func (b *batchSyncer) Sync() error {
+	req := batchRequest{
+		entries: b.take(),
+		flushed: make(chan error, 1),
+	}
+
+	if b.enqueue(req) {
+		return <-req.flushed
+	}
+
+	// The background sender has stopped, so the entries are sent directly.
+	err := b.sendBatch(req.entries)
+
+	b.mu.Lock()
+	if err == nil {
+		err = b.err
+	}
+	b.err = nil
+	b.mu.Unlock()
+
+	return err
+}
+
+// Close stops the background sender once the queued batches are sent, then sends any
+// buffered entries.
+func (b *batchSyncer) Close() error {
+	b.once.Do(func() {
+		b.closeMu.Lock()
+		b.closed = true
+		b.closeMu.Unlock()
+
+		close(b.done)
+	})
+
+	b.wg.Wait()
+
+	return b.Sync()
+}
+
+// enqueue queues the request for the background sender, reporting false once closed.
+func (b *batchSyncer) enqueue(req batchRequest) bool {
+	b.closeMu.RLock()
+	defer b.closeMu.RUnlock()
+
+	if b.closed {
+		return false
+	}
+
+	b.queue <- req
+
+	return true
+}
+
+// take removes and returns the buffered entries.
+func (b *batchSyncer) take() [][]byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	entries := b.entries
+	b.entries = nil
+
+	return entries
+}
+
+// sendBatch sends the entries, doing nothing when there are none.
+func (b *batchSyncer) sendBatch(entries [][]byte) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	return b.send(entries)
+}
+
+// recordError keeps the first error sending a batch in the background for Sync.
+func (b *batchSyncer) recordError(err error) {
+	if err == nil {
+		return
+	}
+
+	b.mu.Lock()
+	if b.err == nil {
+		b.err = err
+	}
+	b.mu.Unlock()
+}
@@ -0,0 +1,83 @@
+package tracelog
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBatchSyncerSendsFullBatchesInBackground(t *testing.T) {
+	release := make(chan struct{})
+
+	var (
+		mu   sync.Mutex
+		sent [][]string
+	)
+
+	b := newBatchSyncer(2, time.Hour, func(entries [][]byte) error {
+		<-release
+
+		batch := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			batch = append(batch, string(entry))
+		}
+
+		mu.Lock()
+		sent = append(sent, batch)
+		mu.Unlock()
+
+		return nil
+	})
+
+	written := make(chan struct{})
+	go func() {
+		defer close(written)
+
+		for _, entry := range []string{"a", "b", "c"} {
+			if _, err := b.Write([]byte(entry)); err != nil {
+				t.Errorf("failed to write entry: %v", err)
+			}
+		}
+	}()
+
+	select {
+	case <-written:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Write blocked on sending a full batch")
+	}
+
+	close(release)
+
+	if err := b.Close(); err != nil {
+		t.Fatalf("failed to close: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(sent) != 2 || len(sent[0]) != 2 || len(sent[1]) != 1 || sent[1][0] != "c" {
+		t.Errorf("sent batches %v, want [[a b] [c]]", sent)
+	}
+}
+
+func TestBatchSyncerSyncReturnsBackgroundErrors(t *testing.T) {
+	errSend := errors.New("unavailable")
+
+	b := newBatchSyncer(1, time.Hour, func([][]byte) error {
+		return errSend
+	})
+	defer b.Close()
+
+	if _, err := b.Write([]byte("a")); err != nil {
+		t.Fatalf("failed to write entry: %v", err)
+	}
+
+	if err := b.Sync(); !errors.Is(err, errSend) {
+		t.Errorf("Sync returned %v, want %v", err, errSend)
+	}
+
+	if err := b.Sync(); err != nil {
+		t.Errorf("second Sync returned %v, want the error to be reported once", err)
+	}
+}
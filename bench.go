@@ -0,0 +1,76 @@
+package tracelog
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// BenchmarkOverhead runs a standard set of log patterns against the TraceLogger and its
+// base logger, reporting the time per entry for each and the ratio between them as the
+// "tracelog-ns/op", "zap-ns/op" and "overhead-ratio" metrics. Both loggers write to the
+// configured output, so use an output that discards entries to measure the loggers alone.
+func BenchmarkOverhead(b *testing.B, tl *TraceLogger) {
+	err := errors.New("benchmark error")
+
+	fields := make([]zap.Field, 10)
+	args := make([]interface{}, len(fields))
+	for i := range fields {
+		fields[i] = zap.String("key"+strconv.Itoa(i), "value")
+		args[i] = fields[i]
+	}
+
+	patterns := []struct {
+		name string
+		tl   func()
+		zap  func()
+	}{
+		{
+			name: "message",
+			tl:   func() { tl.Info("benchmark message") },
+			zap:  func() { tl.base.Info("benchmark message") },
+		},
+		{
+			name: "error",
+			tl:   func() { tl.Info("benchmark message", zap.Error(err)) },
+			zap:  func() { tl.base.Info("benchmark message", zap.Error(err)) },
+		},
+		{
+			name: "fields",
+			tl:   func() { tl.Info("benchmark message", args...) },
+			zap:  func() { tl.base.Info("benchmark message", fields...) },
+		},
+	}
+
+	for _, p := range patterns {
+		p := p
+
+		b.Run(p.name, func(b *testing.B) {
+			b.ReportAllocs()
+
+			start := time.Now()
+			for i := 0; i < b.N; i++ {
+				p.tl()
+			}
+
+			tlNs := float64(time.Since(start).Nanoseconds()) / float64(b.N)
+
+			start = time.Now()
+			for i := 0; i < b.N; i++ {
+				p.zap()
+			}
+
+			zapNs := float64(time.Since(start).Nanoseconds()) / float64(b.N)
+
+			b.ReportMetric(tlNs, "tracelog-ns/op")
+			b.ReportMetric(zapNs, "zap-ns/op")
+
+			if zapNs > 0 {
+				b.ReportMetric(tlNs/zapNs, "overhead-ratio")
+			}
+		})
+	}
+}
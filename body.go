@@ -0,0 +1,66 @@
+package tracelog
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+)
+
+// truncatedMarker is appended to captured bodies exceeding the maximum size.
+const truncatedMarker = "...(truncated)"
+
+// captureBody reads up to max bytes of body, returning the captured body along with a
+// replacement body that still yields the full contents.
+func captureBody(body io.ReadCloser, max int) (string, io.ReadCloser) {
+	if body == nil || body == http.NoBody {
+		return "", body
+	}
+
+	buf, _ := io.ReadAll(io.LimitReader(body, int64(max)+1))
+
+	restored := struct {
+		io.Reader
+		io.Closer
+	}{
+		Reader: io.MultiReader(bytes.NewReader(buf), body),
+		Closer: body,
+	}
+
+	if len(buf) > max {
+		return string(buf[:max]) + truncatedMarker, restored
+	}
+
+	return string(buf), restored
+}
+
+// bodyCaptureWriter captures up to max bytes of the response body. It builds on the
+// ResponseWriter so Flush and Hijack remain available to the handler.
+type bodyCaptureWriter struct {
+	*ResponseWriter
+	max       int
+	buf       bytes.Buffer
+	truncated bool
+}
+
+func (w *bodyCaptureWriter) Write(b []byte) (int, error) {
+	if remaining := w.max - w.buf.Len(); remaining > 0 {
+		if len(b) > remaining {
+			w.buf.Write(b[:remaining])
+			w.truncated = true
+		} else {
+			w.buf.Write(b)
+		}
+	} else if len(b) > 0 {
+		w.truncated = true
+	}
+
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyCaptureWriter) body() string {
+	if w.truncated {
+		return w.buf.String() + truncatedMarker
+	}
+
+	return w.buf.String()
+}
@@ -0,0 +1,60 @@
+package tracelog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestBodyCaptureWriterDelegatesFlush(t *testing.T) {
+	tl, buf := newBufferedLogger()
+
+	h := Middleware(tl, WithBodyCapture(16))(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("hello"))
+
+		f, ok := w.(http.Flusher)
+		if !ok {
+			t.Fatal("response writer does not implement http.Flusher")
+		}
+
+		f.Flush()
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if !rec.Flushed {
+		t.Error("flush was not delegated to the wrapped writer")
+	}
+
+	var found bool
+	for _, entry := range buf.Entries(t) {
+		if entry["responseBody"] == "hello" {
+			found = true
+		}
+	}
+
+	if !found {
+		t.Errorf("response body was not captured in %s", buf.String())
+	}
+}
+
+func TestBodyCaptureWriterHijackUnsupported(t *testing.T) {
+	tl, _ := newBufferedLogger()
+
+	var err error
+	h := Middleware(tl, WithBodyCapture(16))(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		hj, ok := w.(http.Hijacker)
+		if !ok {
+			t.Fatal("response writer does not implement http.Hijacker")
+		}
+
+		_, _, err = hj.Hijack()
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if err == nil {
+		t.Error("expected an error hijacking a writer without hijacking support")
+	}
+}
@@ -0,0 +1,46 @@
+package tracelog
+
+import (
+	"runtime/debug"
+
+	"go.uber.org/zap"
+)
+
+// WithBuildInfo adds the build information embedded in the binary to every entry: the
+// main module's path and version as `build.module` and `build.version`, and the VCS
+// revision and modified flag as `build.commit` and `build.dirty`. Values which were
+// not recorded at build time are skipped.
+func WithBuildInfo() LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl == nil {
+			return
+		}
+
+		info, ok := debug.ReadBuildInfo()
+		if !ok {
+			return
+		}
+
+		var fields []zap.Field
+		if info.Main.Path != "" {
+			fields = append(fields, zap.String("build.module", info.Main.Path))
+		}
+
+		if info.Main.Version != "" {
+			fields = append(fields, zap.String("build.version", info.Main.Version))
+		}
+
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				fields = append(fields, zap.String("build.commit", setting.Value))
+			case "vcs.modified":
+				fields = append(fields, zap.Bool("build.dirty", setting.Value == "true"))
+			}
+		}
+
+		if len(fields) > 0 {
+			tl.zapOpts = append(tl.zapOpts, zap.Fields(fields...))
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package tracelog
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	cloudLoggingTraceKey  = "logging.googleapis.com/trace"
+	cloudLoggingSpanIDKey = "logging.googleapis.com/spanId"
+)
+
+// NewCloudLoggingEncoder creates a `zapcore.Encoder` producing the structured JSON
+// format understood by Google Cloud Logging. The trace ID is emitted as
+// `projects/{projectID}/traces/{traceID}` so entries are linked to their trace. Add the
+// `httpRequest` field using CloudLoggingHTTPRequest.
+func NewCloudLoggingEncoder(projectID string) zapcore.Encoder {
+	enc := zapcore.NewJSONEncoder(zapcore.EncoderConfig{
+		TimeKey:        "timestamp",
+		LevelKey:       "severity",
+		NameKey:        "logger",
+		CallerKey:      "caller",
+		MessageKey:     "message",
+		StacktraceKey:  "stacktrace",
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeLevel:    cloudLoggingSeverityEncoder,
+		EncodeTime:     zapcore.RFC3339NanoTimeEncoder,
+		EncodeDuration: zapcore.SecondsDurationEncoder,
+		EncodeCaller:   zapcore.ShortCallerEncoder,
+	})
+
+	return &mappingEncoder{
+		Encoder: enc,
+		mapString: func(key, val string) (string, string, bool) {
+			switch key {
+			case "traceID":
+				return cloudLoggingTraceKey, fmt.Sprintf("projects/%s/traces/%s", projectID, val), true
+			case "spanID":
+				return cloudLoggingSpanIDKey, val, true
+			case "dd.traceID", "dd.spanID":
+				return key, val, false
+			}
+
+			return key, val, true
+		},
+	}
+}
+
+// WithCloudLoggingOutput encodes entries using the Google Cloud Logging structured
+// format for the provided project. AccessLog entries include the `httpRequest` field.
+func WithCloudLoggingOutput(projectID string) LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl != nil {
+			tl.encoder = NewCloudLoggingEncoder(projectID)
+			tl.cloudLogging = true
+		}
+	}
+}
+
+// CloudLoggingHTTPRequest returns the `httpRequest` field describing a served request,
+// which Google Cloud Logging displays alongside the entry.
+func CloudLoggingHTTPRequest(r *http.Request, status int, size int64, latency time.Duration) zap.Field {
+	return zap.Object("httpRequest", cloudLoggingHTTPRequest{
+		r:       r,
+		status:  status,
+		size:    size,
+		latency: latency,
+	})
+}
+
+// cloudLoggingHTTPRequest encodes the HttpRequest structure of Google Cloud Logging.
+type cloudLoggingHTTPRequest struct {
+	r       *http.Request
+	status  int
+	size    int64
+	latency time.Duration
+}
+
+func (h cloudLoggingHTTPRequest) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	r := h.r
+
+	host := r.Host
+	if host == "" {
+		host = r.URL.Host
+	}
+
+	remoteIP := r.RemoteAddr
+	if ip, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		remoteIP = ip
+	}
+
+	enc.AddString("requestMethod", requestMethod(r))
+	enc.AddString("requestUrl", requestScheme(r)+"://"+host+r.URL.RequestURI())
+	enc.AddInt("status", h.status)
+	// Sizes are 64-bit integers, which the Cloud Logging JSON format encodes as strings.
+	enc.AddString("responseSize", strconv.FormatInt(h.size, 10))
+	enc.AddString("latency", strconv.FormatFloat(h.latency.Seconds(), 'f', -1, 64)+"s")
+	enc.AddString("protocol", r.Proto)
+	enc.AddString("remoteIp", remoteIP)
+
+	if r.ContentLength > 0 {
+		enc.AddString("requestSize", strconv.FormatInt(r.ContentLength, 10))
+	}
+
+	if ua := r.UserAgent(); ua != "" {
+		enc.AddString("userAgent", ua)
+	}
+
+	if ref := r.Referer(); ref != "" {
+		enc.AddString("referer", ref)
+	}
+
+	return nil
+}
+
+func cloudLoggingSeverityEncoder(l zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+	switch l {
+	case zapcore.DebugLevel:
+		enc.AppendString("DEBUG")
+	case zapcore.InfoLevel:
+		enc.AppendString("INFO")
+	case zapcore.WarnLevel:
+		enc.AppendString("WARNING")
+	case zapcore.ErrorLevel:
+		enc.AppendString("ERROR")
+	case zapcore.DPanicLevel:
+		enc.AppendString("CRITICAL")
+	case zapcore.PanicLevel:
+		enc.AppendString("ALERT")
+	case zapcore.FatalLevel:
+		enc.AppendString("EMERGENCY")
+	default:
+		enc.AppendString("DEFAULT")
+	}
+}
@@ -0,0 +1,52 @@
+package tracelog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAccessLogAddsCloudLoggingHTTPRequest(t *testing.T) {
+	tl, buf := newBufferedLogger(WithCloudLoggingOutput("project"))
+
+	r := httptest.NewRequest(http.MethodGet, "http://example.com/items?page=2", nil)
+	r.RemoteAddr = "10.0.0.1:1234"
+	r.Header.Set("User-Agent", "test")
+
+	tl.AccessLog(r, http.StatusOK, 42, 1500*time.Millisecond)
+
+	entries := buf.Entries(t)
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+
+	req, ok := entries[0]["httpRequest"].(map[string]interface{})
+	if !ok {
+		t.Fatalf("entry %v has no httpRequest object", entries[0])
+	}
+
+	for key, want := range map[string]interface{}{
+		"requestMethod": "GET",
+		"requestUrl":    "http://example.com/items?page=2",
+		"status":        float64(http.StatusOK),
+		"responseSize":  "42",
+		"latency":       "1.5s",
+		"remoteIp":      "10.0.0.1",
+		"userAgent":     "test",
+	} {
+		if got := req[key]; got != want {
+			t.Errorf("httpRequest.%s = %v, want %v", key, got, want)
+		}
+	}
+}
+
+func TestAccessLogOmitsHTTPRequestByDefault(t *testing.T) {
+	tl, buf := newBufferedLogger()
+
+	tl.AccessLog(httptest.NewRequest(http.MethodGet, "/", nil), http.StatusOK, 0, time.Millisecond)
+
+	if _, ok := buf.Entries(t)[0]["httpRequest"]; ok {
+		t.Error("httpRequest added without WithCloudLoggingOutput")
+	}
+}
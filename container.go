@@ -0,0 +1,55 @@
+package tracelog
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// cgroupPath is the file the container ID is read from.
+const cgroupPath = "/proc/self/cgroup"
+
+// containerIDPattern matches the 64 character hex container ID, which may be wrapped
+// by the cgroup driver such as in `docker-<id>.scope`.
+var containerIDPattern = regexp.MustCompile(`[0-9a-f]{64}`)
+
+// WithContainerID adds the ID of the container the process is running in to every entry
+// as the `container.id` field, following the OpenTelemetry container semantic
+// conventions. The ID is read from the last path segment of the entries in
+// /proc/self/cgroup. Nothing is added when the ID cannot be found, such as when running
+// outside of a container.
+func WithContainerID() LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl == nil {
+			return
+		}
+
+		if id := containerID(); id != "" {
+			tl.zapOpts = append(tl.zapOpts, zap.Fields(zap.String("container.id", id)))
+		}
+	}
+}
+
+// containerID returns the container ID found in the cgroup file, or an empty string.
+func containerID() string {
+	f, err := os.Open(cgroupPath)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		segment := line[strings.LastIndex(line, "/")+1:]
+
+		if id := containerIDPattern.FindString(segment); id != "" {
+			return id
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,241 @@
+package tracelog
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+type (
+	loggerContextKey  struct{}
+	verboseContextKey struct{}
+)
+
+// NewContext returns a copy of ctx carrying the provided logger.
+func NewContext(ctx context.Context, tl *TraceLogger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, tl)
+}
+
+// FromContext returns the logger carried by ctx, or nil when there is none.
+func FromContext(ctx context.Context) *TraceLogger {
+	tl, _ := ctx.Value(loggerContextKey{}).(*TraceLogger)
+
+	return tl
+}
+
+// ForceVerbose returns a copy of ctx marked so that entries logged using it are written
+// at every level, bypassing the configured level and WithMinTagLevel, and tagged onto
+// the span. The marker is carried by the context, so it propagates through the
+// Middleware when applied to the request's context, e.g. based on a debug header.
+// Levels are only bypassed when the TraceLogger builds the base logger.
+func ForceVerbose(ctx context.Context) context.Context {
+	return context.WithValue(ctx, verboseContextKey{}, true)
+}
+
+// isForcedVerbose reports whether ctx has been marked using ForceVerbose.
+func isForcedVerbose(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+
+	forced, _ := ctx.Value(verboseContextKey{}).(bool)
+
+	return forced
+}
+
+// WithContextFields adds the fields returned by extract to each entry. It is called with
+// the `context.Context` of every log call, so the fields reflect values such as request
+// or user IDs stored in the current context. Use WithContextFieldTagging to also tag the
+// span with the fields.
+func WithContextFields(extract func(context.Context) []zap.Field) LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl != nil {
+			tl.contextFields = extract
+		}
+	}
+}
+
+// WithContextFieldTagging tags the span with the fields added by WithContextFields,
+// converted using FieldsToAttrs, subject to WithMinTagLevel.
+func WithContextFieldTagging() LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl != nil {
+			tl.tagContextFields = true
+		}
+	}
+}
+
+// WithContextCancellationLogging logs the cancellation cause whenever SetContext is
+// provided an already cancelled `context.Context`. See LogContextCancellation.
+func WithContextCancellationLogging() LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl != nil {
+			tl.logCancellation = true
+		}
+	}
+}
+
+// LogContextCancellation logs the cause of the `context.Context` cancellation at WarnLevel
+// and records a `context.cancelled` event on the span. It does nothing when the context
+// has not been cancelled.
+func (tl *TraceLogger) LogContextCancellation(ctx context.Context) {
+	cause := context.Cause(ctx)
+	if cause == nil {
+		return
+	}
+
+	tl.log(ctx, zapcore.WarnLevel, "context cancelled", []interface{}{zap.Error(cause)})
+
+	trace.SpanFromContext(ctx).AddEvent(
+		"context.cancelled",
+		trace.WithAttributes(attribute.String("cause", cause.Error())),
+	)
+}
+
+// Detach returns a logger whose `context.Context` is not cancelled when the logger's
+// context is, for use in background goroutines that outlive a request. The span is
+// retained for correlation, but may end before the goroutine finishes.
+func (tl *TraceLogger) Detach() *TraceLogger {
+	l := tl.clone()
+	if tl.ctx != nil {
+		l.ctx = context.WithoutCancel(tl.ctx)
+	}
+
+	return l
+}
+
+// DebugCtx logs a message at DebugLevel, tagging the span in the provided `context.Context`
+// rather than the logger's. See InfoCtx for details.
+func (tl *TraceLogger) DebugCtx(ctx context.Context, msg string, args ...interface{}) {
+	tl.bindCall(ctx).log(ctx, zapcore.DebugLevel, msg, tl.deadlineArgs(ctx, args))
+}
+
+// InfoCtx logs a message at InfoLevel, tagging the span in the provided `context.Context`
+// rather than the logger's, and using the trace correlation fields of that span. When
+// the context has a deadline, the time remaining is added as the `deadline_remaining`
+// field. When the context has already expired, the `context_expired` field is added and
+// the span status is set to error when the entry is enabled, subject to
+// WithSpanStatusMinLevel and WithStatusMapper.
+func (tl *TraceLogger) InfoCtx(ctx context.Context, msg string, args ...interface{}) {
+	tl.bindCall(ctx).log(ctx, zapcore.InfoLevel, msg, tl.deadlineArgs(ctx, args))
+}
+
+// WarnCtx logs a message at WarnLevel, tagging the span in the provided `context.Context`
+// rather than the logger's. See InfoCtx for details.
+func (tl *TraceLogger) WarnCtx(ctx context.Context, msg string, args ...interface{}) {
+	tl.bindCall(ctx).log(ctx, zapcore.WarnLevel, msg, tl.deadlineArgs(ctx, args))
+}
+
+// ErrorCtx logs a message at ErrorLevel, tagging the span in the provided `context.Context`
+// rather than the logger's. See InfoCtx for details.
+func (tl *TraceLogger) ErrorCtx(ctx context.Context, msg string, args ...interface{}) {
+	tl.bindCall(ctx).log(ctx, zapcore.ErrorLevel, msg, tl.deadlineArgs(ctx, args))
+}
+
+// DPanicCtx logs a message at DPanicLevel, tagging the span in the provided `context.Context`
+// rather than the logger's. See InfoCtx for details.
+func (tl *TraceLogger) DPanicCtx(ctx context.Context, msg string, args ...interface{}) {
+	tl.bindCall(ctx).log(ctx, zapcore.DPanicLevel, msg, tl.deadlineArgs(ctx, args))
+}
+
+// PanicCtx logs a message at PanicLevel, tagging the span in the provided `context.Context`
+// rather than the logger's, then panics. See InfoCtx for details.
+func (tl *TraceLogger) PanicCtx(ctx context.Context, msg string, args ...interface{}) {
+	tl.bindCall(ctx).log(ctx, zapcore.PanicLevel, msg, tl.deadlineArgs(ctx, args))
+}
+
+// FatalCtx logs a message at FatalLevel, tagging the span in the provided `context.Context`
+// rather than the logger's, then calls os.Exit. See InfoCtx for details.
+func (tl *TraceLogger) FatalCtx(ctx context.Context, msg string, args ...interface{}) {
+	tl.bindCall(ctx).log(ctx, zapcore.FatalLevel, msg, tl.deadlineArgs(ctx, args))
+}
+
+// bindCall returns a logger bound to the span in ctx for a single entry, replacing the
+// trace correlation fields like Rebind. tl is returned when it is already bound to the
+// span of ctx.
+func (tl *TraceLogger) bindCall(ctx context.Context) *TraceLogger {
+	spanCtx := trace.SpanContextFromContext(ctx)
+	if tl.ctx != nil && spanCtx.Equal(trace.SpanContextFromContext(tl.ctx)) {
+		return tl
+	}
+
+	if tl.unbound == nil && !spanCtx.IsValid() {
+		return tl
+	}
+
+	l := tl.clone()
+	l.ctx = ctx
+
+	if tl.unbound != nil {
+		l.base, l.verbose = tl.unbound, tl.unboundVerbose
+	}
+
+	if spanCtx.IsValid() {
+		l.bindSpan(tl.traceFields(spanCtx)...)
+	}
+
+	return l
+}
+
+// contextExpired is added to the arguments of an entry when its context has expired.
+// It is logged as the `context_expired` field, and sets the span status to error once
+// the entry passes the level. When WithSpanStatusMinLevel or WithStatusMapper is used,
+// the status is left to them.
+type contextExpired struct{}
+
+// deadlineArgs appends the deadline fields for ctx to args, marking entries whose
+// context has expired with contextExpired.
+func (tl *TraceLogger) deadlineArgs(ctx context.Context, args []interface{}) []interface{} {
+	if deadline, ok := ctx.Deadline(); ok {
+		args = append(args, zap.Duration("deadline_remaining", time.Until(deadline)))
+	}
+
+	if ctx.Err() != nil {
+		args = append(args, contextExpired{})
+	}
+
+	return args
+}
+
+// setExpiredStatus sets the span status in ctx to error when args contain contextExpired,
+// unless the status is left to WithSpanStatusMinLevel or WithStatusMapper.
+func (tl *TraceLogger) setExpiredStatus(ctx context.Context, args []interface{}) {
+	if tl.spanStatus || tl.statusMapper != nil {
+		return
+	}
+
+	for _, arg := range args {
+		if _, ok := arg.(contextExpired); ok {
+			trace.SpanFromContext(ctx).SetStatus(codes.Error, "context expired")
+
+			return
+		}
+	}
+}
+
+// contextField carries the `context.Context` of a log call to cores wrapping the base
+// logger's core. It is a skip field, so it is never encoded, and is only added when
+// such a core is installed, setting contextCores.
+func contextField(ctx context.Context) zap.Field {
+	return zap.Field{Type: zapcore.SkipType, Interface: ctx}
+}
+
+// contextFromFields returns the `context.Context` carried by contextField.
+func contextFromFields(fields []zapcore.Field) (context.Context, bool) {
+	for i := len(fields) - 1; i >= 0; i-- {
+		if fields[i].Type != zapcore.SkipType {
+			continue
+		}
+
+		if ctx, ok := fields[i].Interface.(context.Context); ok {
+			return ctx, true
+		}
+	}
+
+	return nil, false
+}
@@ -0,0 +1,111 @@
+package tracelog
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestContextFieldNotPassedToUserCores(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	tl := NewLogger(WithLogger(zap.New(core)))
+
+	tl.SetContext(contextWithSpan(1, 1)).Info("msg", zap.String("k", "v"))
+
+	for _, f := range logs.All()[0].Context {
+		if f.Type == zapcore.SkipType {
+			t.Errorf("user core received the internal context field %+v", f)
+		}
+	}
+}
+
+func TestContextFieldPassedToContextCores(t *testing.T) {
+	tl, buf := newBufferedLogger(WithDedupWindow(time.Minute))
+
+	lg := tl.SetContext(contextWithSpan(1, 1))
+	lg.Info("retrying")
+	lg.Info("retrying")
+
+	if n := len(buf.Lines()); n != 1 {
+		t.Errorf("got %d entries, want the repeated entry within the trace suppressed", n)
+	}
+}
+
+func TestCtxMethodsUseSpanOfProvidedContext(t *testing.T) {
+	tl, buf := newBufferedLogger()
+
+	lg := tl.SetContext(contextWithSpan(1, 1))
+	other := contextWithSpan(2, 2)
+
+	lg.InfoCtx(other, "tagged")
+	lg.Info("bound")
+
+	entries := buf.Entries(t)
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+
+	want := spanContext(2, 2)
+	if got := entries[0][DefaultFieldNames.TraceID]; got != want.TraceID().String() {
+		t.Errorf("InfoCtx trace ID = %v, want %s", got, want.TraceID())
+	}
+
+	if got := entries[0][DefaultFieldNames.SpanID]; got != want.SpanID().String() {
+		t.Errorf("InfoCtx span ID = %v, want %s", got, want.SpanID())
+	}
+
+	assertUniqueKeys(t, buf.Lines()[0], correlationKeys...)
+
+	if got, want := entries[1][DefaultFieldNames.SpanID], spanContext(1, 1).SpanID().String(); got != want {
+		t.Errorf("logger span ID = %v, want %s", got, want)
+	}
+}
+
+func TestExpiredContextStatusOnlySetForEnabledEntries(t *testing.T) {
+	tests := []struct {
+		name     string
+		log      func(tl *TraceLogger, ctx context.Context)
+		wantCode codes.Code
+	}{
+		{
+			name:     "disabled",
+			log:      func(tl *TraceLogger, ctx context.Context) { tl.DebugCtx(ctx, "gave up") },
+			wantCode: codes.Unset,
+		},
+		{
+			name:     "enabled",
+			log:      func(tl *TraceLogger, ctx context.Context) { tl.InfoCtx(ctx, "gave up") },
+			wantCode: codes.Error,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			rec := useSpanRecorder(t)
+			tl, buf := newBufferedLogger()
+
+			lg, span := tl.SetContext(context.Background()).StartSpan("expired")
+
+			ctx, cancel := context.WithCancel(lg.Context())
+			cancel()
+
+			tt.log(lg, ctx)
+			span.End()
+
+			if got := rec.Ended()[0].Status(); got.Code != tt.wantCode {
+				t.Errorf("status = %+v, want %s", got, tt.wantCode)
+			}
+
+			if tt.wantCode == codes.Error && buf.Entries(t)[0]["context_expired"] != true {
+				t.Errorf("entry %v is not marked as expired", buf.Entries(t)[0])
+			}
+		})
+	}
+}
@@ -0,0 +1,320 @@
+package tracelog
+
+import (
+	"container/list"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// defaultDedupTraceCapacity bounds the entries tracked by WithDedupWindow.
+const defaultDedupTraceCapacity = 256
+
+// dedupState is shared by a deduplication core and the cores derived from it using With.
+type dedupState struct {
+	window   time.Duration
+	capacity int
+
+	// byTrace identifies entries by their trace ID, level and message, ignoring fields.
+	byTrace bool
+
+	mu      sync.Mutex
+	entries map[uint64]*list.Element
+	lru     *list.List
+
+	// timer writes the summaries of suppressed entries once their window expires, so a
+	// storm which stops is still summarized. It is only set while entries are suppressed.
+	timer *time.Timer
+}
+
+// dedupEntry tracks the occurrences of a single entry within the window.
+type dedupEntry struct {
+	key        uint64
+	start      time.Time
+	suppressed int
+	entry      zapcore.Entry
+	core       zapcore.Core
+}
+
+// dedupCore suppresses entries repeated within a window.
+type dedupCore struct {
+	zapcore.Core
+	state *dedupState
+
+	// contextHash identifies the fields added using With.
+	contextHash uint64
+}
+
+// NewDeduplicationCore creates a `zapcore.Core` that suppresses entries with the same
+// level, message and fields as an entry written within the window. The first occurrence
+// is always written. Once the window has expired, a summary entry
+// `suppressed N occurrences of: {message}` is written when the entry next occurs, when
+// it is evicted from the cache of capacity entries, or otherwise by a timer. Fields are
+// compared using their JSON encoding.
+func NewDeduplicationCore(underlying zapcore.Core, window time.Duration, capacity int) zapcore.Core {
+	return &dedupCore{
+		Core:  underlying,
+		state: newDedupState(window, capacity),
+	}
+}
+
+// WithDedupWindow suppresses entries with the same level and message as an entry logged
+// within the window d under the same trace, such as errors logged on every retry.
+// Fields are ignored, and entries without a valid span are not suppressed. Once the
+// window has expired, the entry is written again with the `repeated` field counting the
+// suppressed occurrences when it next occurs, when it is evicted from the bounded cache
+// of recent entries, or otherwise by a timer.
+func WithDedupWindow(d time.Duration) LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl == nil || d <= 0 {
+			return
+		}
+
+		state := newDedupState(d, defaultDedupTraceCapacity)
+		state.byTrace = true
+
+		tl.contextCores = true
+
+		tl.zapOpts = append(tl.zapOpts, zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return &dedupCore{
+				Core:  core,
+				state: state,
+			}
+		}))
+	}
+}
+
+func newDedupState(window time.Duration, capacity int) *dedupState {
+	if capacity < 1 {
+		capacity = 1
+	}
+
+	return &dedupState{
+		window:   window,
+		capacity: capacity,
+		entries:  make(map[uint64]*list.Element, capacity),
+		lru:      list.New(),
+	}
+}
+
+func (c *dedupCore) With(fields []zapcore.Field) zapcore.Core {
+	return &dedupCore{
+		Core:        c.Core.With(fields),
+		state:       c.state,
+		contextHash: hashFields(c.contextHash, fields),
+	}
+}
+
+func (c *dedupCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+
+	return ce
+}
+
+func (c *dedupCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	s := c.state
+
+	h := fnv.New64a()
+	if s.byTrace {
+		ctx, ok := contextFromFields(fields)
+		if !ok {
+			return c.Core.Write(ent, fields)
+		}
+
+		sc := trace.SpanContextFromContext(ctx)
+		if !sc.IsValid() {
+			return c.Core.Write(ent, fields)
+		}
+
+		traceID := sc.TraceID()
+		_, _ = h.Write(traceID[:])
+	} else {
+		_, _ = h.Write([]byte(strconv.FormatUint(c.contextHash, 16)))
+	}
+
+	_, _ = h.Write([]byte(ent.Level.String()))
+	_, _ = h.Write([]byte(ent.Message))
+
+	key := h.Sum64()
+	if !s.byTrace {
+		key = hashFields(key, fields)
+	}
+
+	now := ent.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	var summaries []*dedupEntry
+
+	s.mu.Lock()
+
+	if el, ok := s.entries[key]; ok {
+		e := el.Value.(*dedupEntry)
+		s.lru.MoveToFront(el)
+
+		if now.Sub(e.start) < s.window {
+			e.suppressed++
+			if s.timer == nil {
+				s.timer = time.AfterFunc(e.start.Add(s.window).Sub(now), s.flushExpired)
+			}
+			s.mu.Unlock()
+
+			return nil
+		}
+
+		if e.suppressed > 0 {
+			summary := *e
+			summaries = append(summaries, &summary)
+		}
+
+		e.start = now
+		e.suppressed = 0
+	} else {
+		s.entries[key] = s.lru.PushFront(&dedupEntry{
+			key:   key,
+			start: now,
+			entry: ent,
+			core:  c.Core,
+		})
+
+		for s.lru.Len() > s.capacity {
+			oldest := s.lru.Remove(s.lru.Back()).(*dedupEntry)
+			delete(s.entries, oldest.key)
+
+			if oldest.suppressed > 0 {
+				summaries = append(summaries, oldest)
+			}
+		}
+	}
+
+	s.mu.Unlock()
+
+	err := s.writeSummaries(summaries)
+
+	return multierr.Append(err, c.Core.Write(ent, fields))
+}
+
+// Sync writes the summaries of entries whose window has expired before syncing the
+// underlying core.
+func (c *dedupCore) Sync() error {
+	s := c.state
+
+	s.mu.Lock()
+	summaries := s.expired(time.Now())
+	s.mu.Unlock()
+
+	err := s.writeSummaries(summaries)
+
+	return multierr.Append(err, c.Core.Sync())
+}
+
+// flushExpired writes the summaries of entries whose window has expired, scheduling
+// the timer again for the entries which remain suppressed.
+func (s *dedupState) flushExpired() {
+	now := time.Now()
+
+	s.mu.Lock()
+
+	summaries := s.expired(now)
+
+	s.timer = nil
+
+	var next time.Time
+	for _, el := range s.entries {
+		e := el.Value.(*dedupEntry)
+		if e.suppressed == 0 {
+			continue
+		}
+
+		if end := e.start.Add(s.window); next.IsZero() || end.Before(next) {
+			next = end
+		}
+	}
+
+	if !next.IsZero() {
+		s.timer = time.AfterFunc(next.Sub(now), s.flushExpired)
+	}
+
+	s.mu.Unlock()
+
+	// There is no caller to return the error to, as with zap's own write errors.
+	_ = s.writeSummaries(summaries)
+}
+
+// expired returns the summaries of the suppressed entries whose window has expired at
+// now, resetting their count. It must be called with the lock held.
+func (s *dedupState) expired(now time.Time) []*dedupEntry {
+	var summaries []*dedupEntry
+
+	for _, el := range s.entries {
+		e := el.Value.(*dedupEntry)
+		if e.suppressed == 0 || now.Sub(e.start) < s.window {
+			continue
+		}
+
+		summary := *e
+		summaries = append(summaries, &summary)
+		e.suppressed = 0
+	}
+
+	return summaries
+}
+
+// writeSummaries writes an entry reporting the number of suppressed occurrences of each
+// entry, using the `repeated` field for entries deduplicated by trace.
+func (s *dedupState) writeSummaries(summaries []*dedupEntry) error {
+	var err error
+
+	for _, e := range summaries {
+		ent := e.entry
+		ent.Time = time.Now()
+		ent.Caller = zapcore.EntryCaller{}
+		ent.Stack = ""
+
+		var fields []zapcore.Field
+		if s.byTrace {
+			fields = []zapcore.Field{zap.Int("repeated", e.suppressed)}
+		} else {
+			ent.Message = fmt.Sprintf("suppressed %d occurrences of: %s", e.suppressed, e.entry.Message)
+		}
+
+		err = multierr.Append(err, e.core.Write(ent, fields))
+	}
+
+	return err
+}
+
+// dedupFieldEncoder encodes only the fields of an entry, for hashing their values.
+var dedupFieldEncoder = zapcore.NewJSONEncoder(zapcore.EncoderConfig{})
+
+// hashFields combines the hash seed with the keys and encoded values of fields, so
+// fields holding pointers to equal values hash equally.
+func hashFields(seed uint64, fields []zapcore.Field) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(strconv.FormatUint(seed, 16)))
+
+	buf, err := dedupFieldEncoder.EncodeEntry(zapcore.Entry{}, fields)
+	if err != nil {
+		// Fields which fail to encode are identified by their keys alone.
+		for _, f := range fields {
+			_, _ = h.Write([]byte(f.Key))
+		}
+
+		return h.Sum64()
+	}
+
+	_, _ = h.Write(buf.Bytes())
+	buf.Free()
+
+	return h.Sum64()
+}
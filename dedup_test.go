@@ -0,0 +1,57 @@
+package tracelog
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestDeduplicationCoreSummarizesStoppedStorm(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	lg := zap.New(NewDeduplicationCore(core, 50*time.Millisecond, 16))
+
+	for i := 0; i < 3; i++ {
+		lg.Warn("connection refused")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for logs.Len() < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	entries := logs.All()
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want the first occurrence and a summary", len(entries))
+	}
+
+	if want := "suppressed 2 occurrences of: connection refused"; entries[1].Message != want {
+		t.Errorf("summary = %q, want %q", entries[1].Message, want)
+	}
+}
+
+func TestDeduplicationCoreComparesEncodedFields(t *testing.T) {
+	type request struct {
+		ID string
+	}
+
+	core, logs := observer.New(zapcore.DebugLevel)
+	lg := zap.New(NewDeduplicationCore(core, time.Minute, 16))
+
+	lg.Info("retrying", zap.Any("request", &request{ID: "a"}))
+	lg.Info("retrying", zap.Any("request", &request{ID: "a"}))
+	lg.Info("retrying", zap.Any("request", &request{ID: "b"}))
+
+	if n := logs.Len(); n != 2 {
+		t.Errorf("got %d entries, want pointers to equal values deduplicated", n)
+	}
+
+	for _, e := range logs.All() {
+		if strings.HasPrefix(e.Message, "suppressed") {
+			t.Errorf("unexpected summary %q within the window", e.Message)
+		}
+	}
+}
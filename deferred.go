@@ -0,0 +1,147 @@
+package tracelog
+
+import (
+	"sync"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// A DeferredLogger buffers the entries written to it in memory, writing them to the
+// TraceLogger it was created from only when one of them is at ErrorLevel or above.
+// Spans are tagged as the entries are logged.
+type DeferredLogger struct {
+	*TraceLogger
+	state *deferredState
+}
+
+// deferredState holds the entries buffered by a DeferredLogger and the loggers derived
+// from it.
+type deferredState struct {
+	// enabler filters the entries written once done, as the buffer captures every level.
+	enabler zapcore.LevelEnabler
+
+	mu      sync.Mutex
+	entries []deferredEntry
+	failed  bool
+	done    bool
+}
+
+// deferredEntry is a buffered entry along with the core it is written to.
+type deferredEntry struct {
+	core   zapcore.Core
+	entry  zapcore.Entry
+	fields []zapcore.Field
+}
+
+// NewDeferredLogger creates a DeferredLogger from tl, along with a function which
+// flushes the buffered entries to tl when any were logged at ErrorLevel or above and
+// discards them otherwise. Entries at every level are buffered, so the debug context of
+// a failure is written even when tl's level is higher. Entries above ErrorLevel, such
+// as Panic and Fatal entries, flush the buffer immediately as the process may not
+// continue, and entries logged once the function has been called are written directly
+// when enabled by tl.
+//
+//	dl, flush := tracelog.NewDeferredLogger(tl)
+//	defer flush()
+func NewDeferredLogger(tl *TraceLogger) (*DeferredLogger, func()) {
+	state := &deferredState{enabler: tl.base.Core()}
+
+	l := tl.clone()
+
+	// The buffer captures every level, writing to the verbose logger when flushing.
+	if l.verbose != nil {
+		l.base = l.verbose
+	}
+
+	l.updateBase(func(lg *zap.Logger) *zap.Logger {
+		return lg.WithOptions(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return &deferredCore{
+				Core:  core,
+				state: state,
+			}
+		}))
+	})
+
+	var once sync.Once
+
+	return &DeferredLogger{TraceLogger: l, state: state}, func() {
+		once.Do(func() {
+			state.mu.Lock()
+			defer state.mu.Unlock()
+
+			if err := state.flush(true); err != nil {
+				tl.reportError(err)
+			}
+		})
+	}
+}
+
+// flush writes the buffered entries when one of them failed, discarding them otherwise.
+// Once done, later entries are written directly. The caller must hold the lock.
+func (s *deferredState) flush(done bool) error {
+	var err error
+	if s.failed {
+		for _, e := range s.entries {
+			err = multierr.Append(err, e.core.Write(e.entry, e.fields))
+		}
+	}
+
+	s.entries = nil
+	s.done = s.done || done
+
+	return err
+}
+
+// deferredCore buffers entries in a deferredState.
+type deferredCore struct {
+	zapcore.Core
+	state *deferredState
+}
+
+func (c *deferredCore) With(fields []zapcore.Field) zapcore.Core {
+	return &deferredCore{
+		Core:  c.Core.With(fields),
+		state: c.state,
+	}
+}
+
+func (c *deferredCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+
+	return ce
+}
+
+func (c *deferredCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	s := c.state
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.done {
+		if !s.enabler.Enabled(ent.Level) {
+			return nil
+		}
+
+		return c.Core.Write(ent, fields)
+	}
+
+	// The fields are copied as the caller may reuse the slice once written.
+	s.entries = append(s.entries, deferredEntry{
+		core:   c.Core,
+		entry:  ent,
+		fields: append([]zapcore.Field(nil), fields...),
+	})
+
+	if ent.Level >= zapcore.ErrorLevel {
+		s.failed = true
+	}
+
+	if ent.Level > zapcore.ErrorLevel {
+		return s.flush(false)
+	}
+
+	return nil
+}
@@ -0,0 +1,161 @@
+package tracelog
+
+import (
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+const ecsVersion = "1.6.0"
+
+// ECSConfig describes the service metadata included on every ECS entry.
+type ECSConfig struct {
+	ServiceName    string
+	ServiceVersion string
+}
+
+// ecsFieldNames maps field keys emitted by the TraceLogger, including those of AccessLog,
+// to their Elastic Common Schema equivalents. Keys mapped to an empty string are dropped.
+var ecsFieldNames = map[string]string{
+	"traceID":      "trace.id",
+	"spanID":       "span.id",
+	"dd.traceID":   "",
+	"dd.spanID":    "",
+	"error":        "error.message",
+	"errorVerbose": "error.stack_trace",
+	"method":       "http.request.method",
+	"path":         "url.path",
+	"status":       "http.response.status_code",
+	"size":         "http.response.body.bytes",
+	"duration":     "event.duration",
+	"remoteAddr":   "client.address",
+	"userAgent":    "user_agent.original",
+}
+
+// NewECSEncoder creates a `zapcore.Encoder` producing Elastic Common Schema compliant
+// JSON. Correlation, error and access log fields are renamed into their ECS namespaces
+// whatever their type, which Elasticsearch expands from their dotted keys. The caller
+// is written as the `log.origin.file.name` and `log.origin.file.line` fields.
+func NewECSEncoder(cfg ECSConfig) zapcore.Encoder {
+	enc := zapcore.NewJSONEncoder(zapcore.EncoderConfig{
+		TimeKey:        "@timestamp",
+		LevelKey:       "log.level",
+		NameKey:        "log.logger",
+		MessageKey:     "message",
+		StacktraceKey:  "error.stack_trace",
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeLevel:    zapcore.LowercaseLevelEncoder,
+		EncodeTime:     zapcore.RFC3339NanoTimeEncoder,
+		EncodeDuration: zapcore.NanosDurationEncoder,
+	})
+
+	enc.AddString("ecs.version", ecsVersion)
+
+	if cfg.ServiceName != "" {
+		enc.AddString("service.name", cfg.ServiceName)
+	}
+
+	if cfg.ServiceVersion != "" {
+		enc.AddString("service.version", cfg.ServiceVersion)
+	}
+
+	return &ecsEncoder{
+		mappingEncoder: &mappingEncoder{
+			Encoder:   enc,
+			mapString: ecsKey,
+			mapKey:    ecsFieldName,
+		},
+	}
+}
+
+// WithECSOutput encodes entries using the Elastic Common Schema. The service name
+// defaults to the `OTEL_SERVICE_NAME` environment variable, falling back to the
+// name of the running executable, and the service version defaults to the version
+// of the main module.
+func WithECSOutput(cfg ECSConfig) LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl == nil {
+			return
+		}
+
+		if cfg.ServiceName == "" {
+			cfg.ServiceName = defaultServiceName()
+		}
+
+		if cfg.ServiceVersion == "" {
+			cfg.ServiceVersion = defaultServiceVersion()
+		}
+
+		tl.encoder = NewECSEncoder(cfg)
+	}
+}
+
+// ecsEncoder writes the caller of each entry using the ECS `log.origin` fields.
+type ecsEncoder struct {
+	*mappingEncoder
+}
+
+func (e *ecsEncoder) Clone() zapcore.Encoder {
+	return &ecsEncoder{
+		mappingEncoder: e.mappingEncoder.Clone().(*mappingEncoder),
+	}
+}
+
+func (e *ecsEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	if ent.Caller.Defined {
+		line := strconv.Itoa(ent.Caller.Line)
+		fields = append(
+			fields[:len(fields):len(fields)],
+			zap.String("log.origin.file.name", strings.TrimSuffix(ent.Caller.TrimmedPath(), ":"+line)),
+			zap.Int("log.origin.file.line", ent.Caller.Line),
+		)
+	}
+
+	return e.mappingEncoder.EncodeEntry(ent, fields)
+}
+
+// defaultServiceName returns the `OTEL_SERVICE_NAME` environment variable, falling
+// back to the name of the running executable.
+func defaultServiceName() string {
+	if name := os.Getenv("OTEL_SERVICE_NAME"); name != "" {
+		return name
+	}
+
+	return filepath.Base(os.Args[0])
+}
+
+// defaultServiceVersion returns the version of the main module, or an empty string when
+// it is not known, such as for binaries built from a local checkout.
+func defaultServiceVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok || info.Main.Version == "(devel)" {
+		return ""
+	}
+
+	return info.Main.Version
+}
+
+// ecsKey returns the ECS name for the key of a string field, and false when the field
+// should be dropped.
+func ecsKey(key, val string) (string, string, bool) {
+	mapped, ok := ecsFieldName(key)
+
+	return mapped, val, ok
+}
+
+// ecsFieldName returns the ECS name for the key, and false when the field should be
+// dropped.
+func ecsFieldName(key string) (string, bool) {
+	mapped, ok := ecsFieldNames[key]
+	if !ok {
+		return key, true
+	}
+
+	return mapped, mapped != ""
+}
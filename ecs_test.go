@@ -0,0 +1,66 @@
+package tracelog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithECSOutputUsesConfig(t *testing.T) {
+	tl, buf := newBufferedLogger(WithECSOutput(ECSConfig{ServiceName: "checkout", ServiceVersion: "1.2.3"}))
+
+	tl.Info("msg")
+
+	entry := buf.Entries(t)[0]
+	for key, want := range map[string]interface{}{
+		"service.name":    "checkout",
+		"service.version": "1.2.3",
+		"message":         "msg",
+	} {
+		if got := entry[key]; got != want {
+			t.Errorf("%s = %v, want %v", key, got, want)
+		}
+	}
+}
+
+func TestWithECSOutputSplitsCaller(t *testing.T) {
+	tl, buf := newBufferedLogger(WithECSOutput(ECSConfig{}))
+
+	tl.Info("msg")
+
+	entry := buf.Entries(t)[0]
+	if name, _ := entry["log.origin.file.name"].(string); !strings.HasSuffix(name, "ecs_test.go") {
+		t.Errorf("log.origin.file.name = %v, want the test file", entry["log.origin.file.name"])
+	}
+
+	if line, _ := entry["log.origin.file.line"].(float64); line <= 0 {
+		t.Errorf("log.origin.file.line = %v, want a line number", entry["log.origin.file.line"])
+	}
+}
+
+func TestWithECSOutputMapsNonStringFields(t *testing.T) {
+	tl, buf := newBufferedLogger(WithECSOutput(ECSConfig{}))
+
+	tl.AccessLog(httptest.NewRequest(http.MethodGet, "/items", nil), http.StatusNotFound, 12, time.Millisecond)
+
+	entry := buf.Entries(t)[0]
+	for key, want := range map[string]interface{}{
+		"http.request.method":       "GET",
+		"url.path":                  "/items",
+		"http.response.status_code": float64(http.StatusNotFound),
+		"http.response.body.bytes":  float64(12),
+		"event.duration":            float64(time.Millisecond),
+	} {
+		if got := entry[key]; got != want {
+			t.Errorf("%s = %v, want %v", key, got, want)
+		}
+	}
+
+	for _, key := range []string{"status", "size", "duration"} {
+		if _, ok := entry[key]; ok {
+			t.Errorf("unmapped key %q written", key)
+		}
+	}
+}
@@ -0,0 +1,340 @@
+package tracelog
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// mappingEncoder wraps a `zapcore.Encoder`, rewriting fields before they are encoded.
+// This is used to map the fields emitted by the TraceLogger onto the schema expected by
+// a log backend.
+type mappingEncoder struct {
+	zapcore.Encoder
+
+	// mapString returns the key and value to encode, and false when the field
+	// should be dropped.
+	mapString func(key, val string) (string, string, bool)
+
+	// mapKey returns the key to encode fields of other types under, and false when
+	// the field should be dropped. Keys are not mapped when it is nil.
+	mapKey func(key string) (string, bool)
+}
+
+func (e *mappingEncoder) Clone() zapcore.Encoder {
+	return &mappingEncoder{
+		Encoder:   e.Encoder.Clone(),
+		mapString: e.mapString,
+		mapKey:    e.mapKey,
+	}
+}
+
+// key maps the key of a field which is not a string.
+func (e *mappingEncoder) key(key string) (string, bool) {
+	if e.mapKey == nil {
+		return key, true
+	}
+
+	return e.mapKey(key)
+}
+
+func (e *mappingEncoder) AddString(key, val string) {
+	if key, val, ok := e.mapString(key, val); ok {
+		e.Encoder.AddString(key, val)
+	}
+}
+
+func (e *mappingEncoder) AddArray(key string, val zapcore.ArrayMarshaler) error {
+	if key, ok := e.key(key); ok {
+		return e.Encoder.AddArray(key, val)
+	}
+
+	return nil
+}
+
+func (e *mappingEncoder) AddObject(key string, val zapcore.ObjectMarshaler) error {
+	if key, ok := e.key(key); ok {
+		return e.Encoder.AddObject(key, val)
+	}
+
+	return nil
+}
+
+func (e *mappingEncoder) AddReflected(key string, val interface{}) error {
+	if key, ok := e.key(key); ok {
+		return e.Encoder.AddReflected(key, val)
+	}
+
+	return nil
+}
+
+func (e *mappingEncoder) AddBinary(key string, val []byte) {
+	if key, ok := e.key(key); ok {
+		e.Encoder.AddBinary(key, val)
+	}
+}
+
+func (e *mappingEncoder) AddByteString(key string, val []byte) {
+	if key, ok := e.key(key); ok {
+		e.Encoder.AddByteString(key, val)
+	}
+}
+
+func (e *mappingEncoder) AddBool(key string, val bool) {
+	if key, ok := e.key(key); ok {
+		e.Encoder.AddBool(key, val)
+	}
+}
+
+func (e *mappingEncoder) AddComplex128(key string, val complex128) {
+	if key, ok := e.key(key); ok {
+		e.Encoder.AddComplex128(key, val)
+	}
+}
+
+func (e *mappingEncoder) AddComplex64(key string, val complex64) {
+	if key, ok := e.key(key); ok {
+		e.Encoder.AddComplex64(key, val)
+	}
+}
+
+func (e *mappingEncoder) AddDuration(key string, val time.Duration) {
+	if key, ok := e.key(key); ok {
+		e.Encoder.AddDuration(key, val)
+	}
+}
+
+func (e *mappingEncoder) AddFloat64(key string, val float64) {
+	if key, ok := e.key(key); ok {
+		e.Encoder.AddFloat64(key, val)
+	}
+}
+
+func (e *mappingEncoder) AddFloat32(key string, val float32) {
+	if key, ok := e.key(key); ok {
+		e.Encoder.AddFloat32(key, val)
+	}
+}
+
+func (e *mappingEncoder) AddInt(key string, val int) {
+	if key, ok := e.key(key); ok {
+		e.Encoder.AddInt(key, val)
+	}
+}
+
+func (e *mappingEncoder) AddInt64(key string, val int64) {
+	if key, ok := e.key(key); ok {
+		e.Encoder.AddInt64(key, val)
+	}
+}
+
+func (e *mappingEncoder) AddInt32(key string, val int32) {
+	if key, ok := e.key(key); ok {
+		e.Encoder.AddInt32(key, val)
+	}
+}
+
+func (e *mappingEncoder) AddInt16(key string, val int16) {
+	if key, ok := e.key(key); ok {
+		e.Encoder.AddInt16(key, val)
+	}
+}
+
+func (e *mappingEncoder) AddInt8(key string, val int8) {
+	if key, ok := e.key(key); ok {
+		e.Encoder.AddInt8(key, val)
+	}
+}
+
+func (e *mappingEncoder) AddTime(key string, val time.Time) {
+	if key, ok := e.key(key); ok {
+		e.Encoder.AddTime(key, val)
+	}
+}
+
+func (e *mappingEncoder) AddUint(key string, val uint) {
+	if key, ok := e.key(key); ok {
+		e.Encoder.AddUint(key, val)
+	}
+}
+
+func (e *mappingEncoder) AddUint64(key string, val uint64) {
+	if key, ok := e.key(key); ok {
+		e.Encoder.AddUint64(key, val)
+	}
+}
+
+func (e *mappingEncoder) AddUint32(key string, val uint32) {
+	if key, ok := e.key(key); ok {
+		e.Encoder.AddUint32(key, val)
+	}
+}
+
+func (e *mappingEncoder) AddUint16(key string, val uint16) {
+	if key, ok := e.key(key); ok {
+		e.Encoder.AddUint16(key, val)
+	}
+}
+
+func (e *mappingEncoder) AddUint8(key string, val uint8) {
+	if key, ok := e.key(key); ok {
+		e.Encoder.AddUint8(key, val)
+	}
+}
+
+func (e *mappingEncoder) AddUintptr(key string, val uintptr) {
+	if key, ok := e.key(key); ok {
+		e.Encoder.AddUintptr(key, val)
+	}
+}
+
+func (e *mappingEncoder) OpenNamespace(key string) {
+	if key, ok := e.key(key); ok {
+		e.Encoder.OpenNamespace(key)
+	}
+}
+
+func (e *mappingEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	// Fields are added through the wrapper so keys produced while encoding, such
+	// as the verbose form of an error, are mapped as well.
+	clone := &mappingEncoder{
+		Encoder:   e.Encoder.Clone(),
+		mapString: e.mapString,
+		mapKey:    e.mapKey,
+	}
+	for _, f := range fields {
+		f.AddTo(clone)
+	}
+
+	return clone.Encoder.EncodeEntry(ent, nil)
+}
+
+// entryLevel decodes the level from a JSON encoded entry, reporting whether a valid
+// level was found under the "level" key.
+func entryLevel(p []byte) (zapcore.Level, bool) {
+	var entry struct {
+		Level string `json:"level"`
+	}
+
+	if err := json.Unmarshal(p, &entry); err != nil || entry.Level == "" {
+		return zapcore.InfoLevel, false
+	}
+
+	var lvl zapcore.Level
+	if err := lvl.UnmarshalText([]byte(entry.Level)); err != nil {
+		return zapcore.InfoLevel, false
+	}
+
+	return lvl, true
+}
+
+// logfmtPool provides the buffers used by the logfmt encoder.
+var logfmtPool = buffer.NewPool()
+
+// logfmtEncoder encodes entries as logfmt `key=value` pairs. Entries are encoded using
+// the wrapped JSON encoder before being converted, so every zap field type and encoder
+// config option is supported. Nested objects and arrays are written as quoted JSON.
+type logfmtEncoder struct {
+	zapcore.Encoder
+
+	lineEnding string
+}
+
+// NewLogfmtEncoder creates a `zapcore.Encoder` producing logfmt `key=value` pairs using
+// the keys and encoders of the provided config.
+func NewLogfmtEncoder(cfg zapcore.EncoderConfig) zapcore.Encoder {
+	lineEnding := cfg.LineEnding
+	if lineEnding == "" {
+		lineEnding = zapcore.DefaultLineEnding
+	}
+
+	cfg.LineEnding = zapcore.DefaultLineEnding
+
+	return &logfmtEncoder{
+		Encoder:    zapcore.NewJSONEncoder(cfg),
+		lineEnding: lineEnding,
+	}
+}
+
+func (e *logfmtEncoder) Clone() zapcore.Encoder {
+	return &logfmtEncoder{
+		Encoder:    e.Encoder.Clone(),
+		lineEnding: e.lineEnding,
+	}
+}
+
+func (e *logfmtEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	encoded, err := e.Encoder.EncodeEntry(ent, fields)
+	if err != nil {
+		return nil, err
+	}
+	defer encoded.Free()
+
+	dec := json.NewDecoder(bytes.NewReader(encoded.Bytes()))
+	if _, err := dec.Token(); err != nil {
+		return nil, fmt.Errorf("failed to decode entry: %w", err)
+	}
+
+	buf := logfmtPool.Get()
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			buf.Free()
+			return nil, fmt.Errorf("failed to decode entry: %w", err)
+		}
+
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			buf.Free()
+			return nil, fmt.Errorf("failed to decode entry: %w", err)
+		}
+
+		if buf.Len() > 0 {
+			buf.AppendByte(' ')
+		}
+
+		key, _ := tok.(string)
+		buf.AppendString(logfmtKey(key))
+		buf.AppendByte('=')
+		buf.AppendString(logfmtValue(raw))
+	}
+
+	buf.AppendString(e.lineEnding)
+
+	return buf, nil
+}
+
+// logfmtKey replaces the characters which cannot appear in a logfmt key.
+func logfmtKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		if r <= ' ' || r == '=' || r == '"' {
+			return '_'
+		}
+
+		return r
+	}, key)
+}
+
+// logfmtValue converts a JSON encoded value into a logfmt value, quoting it when
+// required.
+func logfmtValue(raw json.RawMessage) string {
+	val := string(raw)
+	if len(raw) > 0 && raw[0] == '"' {
+		if err := json.Unmarshal(raw, &val); err != nil {
+			val = string(raw)
+		}
+	}
+
+	if val == "" || strings.ContainsAny(val, " =\"\\") || strings.IndexFunc(val, unicode.IsControl) >= 0 {
+		return strconv.Quote(val)
+	}
+
+	return val
+}
@@ -0,0 +1,31 @@
+package tracelog
+
+import (
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestLogfmtEncoderCorrelationFields(t *testing.T) {
+	tl, buf := newBufferedLogger(WithLogfmtEncoder())
+	sc := spanContext(1, 2)
+
+	tl.SetContext(contextWithSpan(1, 2)).Info("hello world", zap.String("k", "v"))
+
+	line := strings.TrimSpace(buf.String())
+	for _, pair := range []string{
+		"traceID=" + sc.TraceID().String(),
+		"spanID=" + sc.SpanID().String(),
+		`msg="hello world"`,
+		"k=v",
+	} {
+		if !strings.Contains(" "+line+" ", " "+pair+" ") {
+			t.Errorf("logfmt entry %q does not contain %s", line, pair)
+		}
+	}
+
+	if strings.HasPrefix(line, "{") {
+		t.Errorf("entry is encoded as JSON: %s", line)
+	}
+}
@@ -0,0 +1,183 @@
+package tracelog
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// encryptedFrameHeaderSize is the size of the big-endian length prefix of each frame.
+const encryptedFrameHeaderSize = 4
+
+// maxEncryptedFrameSize bounds the size of a frame following its length prefix, so a
+// corrupt or malicious length cannot make the reader allocate an arbitrary amount.
+const maxEncryptedFrameSize = 16 << 20
+
+// encryptedWriteSyncer encrypts each entry before writing it to the underlying WriteSyncer.
+type encryptedWriteSyncer struct {
+	underlying zapcore.WriteSyncer
+	aead       cipher.AEAD
+
+	mu sync.Mutex
+}
+
+// NewEncryptedWriteSyncer creates a `zapcore.WriteSyncer` that encrypts each entry using
+// AES-256-GCM with the provided 32 byte key. Each entry is written to underlying as a
+// frame containing a 4 byte big-endian length, followed by a random 12 byte nonce and
+// the ciphertext. Entries producing frames larger than 16 MiB are rejected. Use
+// NewDecryptedReader to read the entries back.
+func NewEncryptedWriteSyncer(underlying zapcore.WriteSyncer, key []byte) (zapcore.WriteSyncer, error) {
+	aead, err := newAESGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &encryptedWriteSyncer{
+		underlying: underlying,
+		aead:       aead,
+	}, nil
+}
+
+// WithEncryptedOutput encrypts entries written to the output using AES-256-GCM with
+// the provided key. See NewEncryptedWriteSyncer.
+func WithEncryptedOutput(key []byte) LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl == nil {
+			return
+		}
+
+		if _, err := newAESGCM(key); err != nil {
+			tl.reportError(fmt.Errorf("failed to create encrypted output: %w", err))
+
+			return
+		}
+
+		tl.encryptionKey = key
+	}
+}
+
+func (ws *encryptedWriteSyncer) Write(p []byte) (int, error) {
+	nonceSize := ws.aead.NonceSize()
+	if size := nonceSize + len(p) + ws.aead.Overhead(); size > maxEncryptedFrameSize {
+		return 0, fmt.Errorf("encrypted entry of %d bytes exceeds the maximum frame size of %d bytes", size, maxEncryptedFrameSize)
+	}
+
+	frame := make([]byte, encryptedFrameHeaderSize+nonceSize, encryptedFrameHeaderSize+nonceSize+len(p)+ws.aead.Overhead())
+
+	nonce := frame[encryptedFrameHeaderSize:]
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return 0, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	frame = ws.aead.Seal(frame, nonce, p, nil)
+	binary.BigEndian.PutUint32(frame, uint32(len(frame)-encryptedFrameHeaderSize))
+
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if _, err := ws.underlying.Write(frame); err != nil {
+		return 0, fmt.Errorf("failed to write encrypted entry: %w", err)
+	}
+
+	return len(p), nil
+}
+
+func (ws *encryptedWriteSyncer) Sync() error {
+	return ws.underlying.Sync()
+}
+
+// decryptedReader reads the frames written by the encrypted WriteSyncer, returning
+// the decrypted entries.
+type decryptedReader struct {
+	r    *bufio.Reader
+	aead cipher.AEAD
+	err  error
+	buf  bytes.Buffer
+}
+
+// NewDecryptedReader returns an `io.Reader` decrypting the entries written using
+// NewEncryptedWriteSyncer with the same key. Reading fails on frames larger than the
+// writer produces.
+func NewDecryptedReader(r io.Reader, key []byte) io.Reader {
+	aead, err := newAESGCM(key)
+
+	return &decryptedReader{
+		r:    bufio.NewReader(r),
+		aead: aead,
+		err:  err,
+	}
+}
+
+func (d *decryptedReader) Read(p []byte) (int, error) {
+	for d.buf.Len() == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+
+		d.err = d.readFrame()
+	}
+
+	return d.buf.Read(p)
+}
+
+// readFrame decrypts the next frame into the buffer.
+func (d *decryptedReader) readFrame() error {
+	var header [encryptedFrameHeaderSize]byte
+	if _, err := io.ReadFull(d.r, header[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return fmt.Errorf("failed to read frame header: %w", err)
+		}
+
+		return err
+	}
+
+	size := binary.BigEndian.Uint32(header[:])
+	if size > maxEncryptedFrameSize {
+		return fmt.Errorf("frame of %d bytes exceeds the maximum frame size of %d bytes", size, maxEncryptedFrameSize)
+	}
+
+	frame := make([]byte, size)
+	if _, err := io.ReadFull(d.r, frame); err != nil {
+		return fmt.Errorf("failed to read frame: %w", io.ErrUnexpectedEOF)
+	}
+
+	nonceSize := d.aead.NonceSize()
+	if len(frame) < nonceSize {
+		return fmt.Errorf("frame of %d bytes is too short", len(frame))
+	}
+
+	plaintext, err := d.aead.Open(nil, frame[:nonceSize], frame[nonceSize:], nil)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt frame: %w", err)
+	}
+
+	d.buf.Write(plaintext)
+
+	return nil
+}
+
+func newAESGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("invalid key size %d, AES-256 requires a 32 byte key", len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+
+	return aead, nil
+}
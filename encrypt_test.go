@@ -0,0 +1,82 @@
+package tracelog
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// testEncryptionKey is a 32 byte AES-256 key.
+var testEncryptionKey = []byte("0123456789abcdef0123456789abcdef")
+
+func TestEncryptedRoundTrip(t *testing.T) {
+	var out bytes.Buffer
+
+	ws, err := NewEncryptedWriteSyncer(zapcore.AddSync(&out), testEncryptionKey)
+	if err != nil {
+		t.Fatalf("failed to create encrypted write syncer: %v", err)
+	}
+
+	entries := []string{
+		`{"msg":"first"}` + "\n",
+		`{"msg":"second","traceID":"0102"}` + "\n",
+	}
+
+	for _, entry := range entries {
+		if _, err := ws.Write([]byte(entry)); err != nil {
+			t.Fatalf("failed to write entry: %v", err)
+		}
+	}
+
+	if bytes.Contains(out.Bytes(), []byte("first")) {
+		t.Error("entry was written in plaintext")
+	}
+
+	got, err := io.ReadAll(NewDecryptedReader(&out, testEncryptionKey))
+	if err != nil {
+		t.Fatalf("failed to decrypt entries: %v", err)
+	}
+
+	if want := strings.Join(entries, ""); string(got) != want {
+		t.Errorf("decrypted %q, want %q", got, want)
+	}
+}
+
+func TestEncryptedOutputRoundTrip(t *testing.T) {
+	tl, buf := newBufferedLogger(WithEncryptedOutput(testEncryptionKey))
+	tl.SetContext(contextWithSpan(1, 2)).Info("secret")
+
+	got, err := io.ReadAll(NewDecryptedReader(strings.NewReader(buf.String()), testEncryptionKey))
+	if err != nil {
+		t.Fatalf("failed to decrypt entries: %v", err)
+	}
+
+	if !bytes.Contains(got, []byte(`"msg":"secret"`)) || !bytes.Contains(got, []byte(spanContext(1, 2).TraceID().String())) {
+		t.Errorf("decrypted entry %s does not contain the message and trace ID", got)
+	}
+}
+
+func TestDecryptedReaderRejectsOversizedFrames(t *testing.T) {
+	var header [encryptedFrameHeaderSize]byte
+	binary.BigEndian.PutUint32(header[:], maxEncryptedFrameSize+1)
+
+	_, err := io.ReadAll(NewDecryptedReader(bytes.NewReader(header[:]), testEncryptionKey))
+	if err == nil || !strings.Contains(err.Error(), "maximum frame size") {
+		t.Errorf("got error %v, want the frame to be rejected", err)
+	}
+}
+
+func TestEncryptedWriteSyncerRejectsOversizedEntries(t *testing.T) {
+	ws, err := NewEncryptedWriteSyncer(zapcore.AddSync(io.Discard), testEncryptionKey)
+	if err != nil {
+		t.Fatalf("failed to create encrypted write syncer: %v", err)
+	}
+
+	if _, err := ws.Write(make([]byte, maxEncryptedFrameSize)); err == nil {
+		t.Error("expected an error writing an entry exceeding the maximum frame size")
+	}
+}
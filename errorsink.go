@@ -0,0 +1,65 @@
+package tracelog
+
+import (
+	"sync/atomic"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// errorSink is a `zapcore.WriteSyncer` whose target can be swapped atomically. It is
+// also the LevelEnabler of its core, enabling ErrorLevel and above only while a target
+// is set, so entries are not encoded for it otherwise.
+type errorSink struct {
+	target atomic.Pointer[errorSinkTarget]
+}
+
+// errorSinkTarget holds the target of an errorSink, as atomic.Pointer requires a
+// concrete type.
+type errorSinkTarget struct {
+	ws zapcore.WriteSyncer
+}
+
+// SetErrorSink additionally writes entries at ErrorLevel and above to ws, replacing any
+// previous error sink, or stops writing them when ws is nil. The sink is shared by the
+// loggers derived from tl, and can be swapped at runtime, such as to enable a remote
+// sink during an incident, without rebuilding the logger. Writes already in flight
+// complete using the previous sink.
+func (tl *TraceLogger) SetErrorSink(ws zapcore.WriteSyncer) {
+	if tl.errorSink == nil {
+		return
+	}
+
+	if ws == nil {
+		tl.errorSink.target.Store(nil)
+		return
+	}
+
+	tl.errorSink.target.Store(&errorSinkTarget{ws: ws})
+}
+
+// errorSinkCore writes entries to the error sink in addition to the provided core.
+func (tl *TraceLogger) errorSinkCore(core zapcore.Core) zapcore.Core {
+	return zapcore.NewTee(core, zapcore.NewCore(tl.newEncoder(), tl.errorSink, tl.errorSink))
+}
+
+func (s *errorSink) Enabled(lvl zapcore.Level) bool {
+	return lvl >= zapcore.ErrorLevel && s.target.Load() != nil
+}
+
+func (s *errorSink) Write(p []byte) (int, error) {
+	t := s.target.Load()
+	if t == nil {
+		return len(p), nil
+	}
+
+	return t.ws.Write(p)
+}
+
+func (s *errorSink) Sync() error {
+	t := s.target.Load()
+	if t == nil {
+		return nil
+	}
+
+	return t.ws.Sync()
+}
@@ -0,0 +1,346 @@
+package tracelog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+// Codec is the compression algorithm used by the compressed file WriteSyncer.
+type Codec int
+
+const (
+	// CodecSnappy compresses entries using the Snappy framing format.
+	CodecSnappy Codec = iota
+	// CodecZstd compresses entries as Zstandard frames.
+	CodecZstd
+)
+
+const (
+	defaultCompressedBlockSize     = 64 << 10
+	defaultCompressedFlushInterval = time.Second
+)
+
+type fileConfig struct {
+	maxSizeMB  int
+	maxAgeDays int
+	maxBackups int
+	compress   bool
+
+	blockSize     int
+	flushInterval time.Duration
+}
+
+// FileOption configures the rotation of file based WriteSyncers, and the blocks of the
+// compressed file WriteSyncer.
+type FileOption func(*fileConfig)
+
+// RollingFileOption configures the rolling file WriteSyncer.
+type RollingFileOption = FileOption
+
+// WithMaxSizeMB sets the maximum size in megabytes of the file before it is rotated.
+// Defaults to 100 megabytes.
+func WithMaxSizeMB(n int) FileOption {
+	return func(cfg *fileConfig) {
+		if cfg != nil {
+			cfg.maxSizeMB = n
+		}
+	}
+}
+
+// WithMaxAgeDays sets the maximum number of days to retain rotated files. By default
+// rotated files are not removed based on age.
+func WithMaxAgeDays(n int) FileOption {
+	return func(cfg *fileConfig) {
+		if cfg != nil {
+			cfg.maxAgeDays = n
+		}
+	}
+}
+
+// WithMaxBackups sets the maximum number of rotated files to retain. By default all
+// rotated files are retained.
+func WithMaxBackups(n int) FileOption {
+	return func(cfg *fileConfig) {
+		if cfg != nil {
+			cfg.maxBackups = n
+		}
+	}
+}
+
+// WithCompress gzip compresses rotated files.
+func WithCompress() FileOption {
+	return func(cfg *fileConfig) {
+		if cfg != nil {
+			cfg.compress = true
+		}
+	}
+}
+
+// WithCompressedBlockSize sets the number of bytes of entries buffered by the compressed
+// file WriteSyncer before they are compressed into a block. Defaults to 64 KiB.
+func WithCompressedBlockSize(n int) FileOption {
+	return func(cfg *fileConfig) {
+		if cfg != nil && n > 0 {
+			cfg.blockSize = n
+		}
+	}
+}
+
+// WithCompressedFlushInterval sets the maximum amount of time entries are buffered by
+// the compressed file WriteSyncer before they are compressed into a block. Defaults to
+// one second.
+func WithCompressedFlushInterval(d time.Duration) FileOption {
+	return func(cfg *fileConfig) {
+		if cfg != nil && d > 0 {
+			cfg.flushInterval = d
+		}
+	}
+}
+
+// newFileConfig applies the options to the default file configuration.
+func newFileConfig(opts []FileOption) *fileConfig {
+	cfg := &fileConfig{
+		blockSize:     defaultCompressedBlockSize,
+		flushInterval: defaultCompressedFlushInterval,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return cfg
+}
+
+// newFileLogger creates the rotating file writer for the provided path.
+func newFileLogger(path string, cfg *fileConfig) *lumberjack.Logger {
+	return &lumberjack.Logger{
+		Filename:   path,
+		MaxSize:    cfg.maxSizeMB,
+		MaxAge:     cfg.maxAgeDays,
+		MaxBackups: cfg.maxBackups,
+		Compress:   cfg.compress,
+	}
+}
+
+// rollingFileSyncer adapts the rotating file writer to a `zapcore.WriteSyncer`.
+type rollingFileSyncer struct {
+	*lumberjack.Logger
+}
+
+// NewRollingFileSyncer creates a `zapcore.WriteSyncer` that appends entries to the file
+// at path, rotating it according to the provided options. The returned WriteSyncer
+// implements `io.Closer`.
+func NewRollingFileSyncer(path string, opts ...RollingFileOption) (zapcore.WriteSyncer, error) {
+	if path == "" {
+		return nil, errors.New("file path is required")
+	}
+
+	return &rollingFileSyncer{Logger: newFileLogger(path, newFileConfig(opts))}, nil
+}
+
+// WithRollingFileOutput additionally writes entries to the file at path, rotating it
+// according to the provided options. Entries continue to be written to the current output.
+// The file is closed by Close.
+func WithRollingFileOutput(path string, opts ...RollingFileOption) LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl == nil {
+			return
+		}
+
+		ws, err := NewRollingFileSyncer(path, opts...)
+		if err != nil {
+			tl.reportError(fmt.Errorf("failed to create rolling file output: %w", err))
+
+			return
+		}
+
+		tl.teeOutputs = append(tl.teeOutputs, ws)
+		tl.addCloser(ws)
+	}
+}
+
+func (s *rollingFileSyncer) Sync() error {
+	return nil
+}
+
+// compressedFileSyncer buffers entries and compresses them into self-contained blocks
+// before appending them to the file, so the file can be decoded as a sequence of blocks
+// even after rotation.
+type compressedFileSyncer struct {
+	file          *lumberjack.Logger
+	compress      func([]byte) ([]byte, error)
+	blockSize     int
+	flushInterval time.Duration
+
+	mu  sync.Mutex
+	buf bytes.Buffer
+
+	once sync.Once
+	done chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewCompressedFileSyncer creates a `zapcore.WriteSyncer` that appends entries to the
+// file at path, compressed using the provided codec. Entries are buffered and compressed
+// together as an independent block once the block size is reached, the flush interval
+// elapses, or Sync is called. The returned WriteSyncer implements `io.Closer`, which
+// writes the buffered entries.
+func NewCompressedFileSyncer(path string, codec Codec, opts ...FileOption) (zapcore.WriteSyncer, error) {
+	if path == "" {
+		return nil, errors.New("file path is required")
+	}
+
+	var compress func([]byte) ([]byte, error)
+
+	switch codec {
+	case CodecSnappy:
+		compress = func(p []byte) ([]byte, error) {
+			var buf bytes.Buffer
+
+			w := snappy.NewBufferedWriter(&buf)
+			if _, err := w.Write(p); err != nil {
+				return nil, err
+			}
+
+			if err := w.Close(); err != nil {
+				return nil, err
+			}
+
+			return buf.Bytes(), nil
+		}
+	case CodecZstd:
+		enc, err := zstd.NewWriter(nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create zstd encoder: %w", err)
+		}
+
+		compress = func(p []byte) ([]byte, error) {
+			return enc.EncodeAll(p, nil), nil
+		}
+	default:
+		return nil, fmt.Errorf("unsupported codec: %d", codec)
+	}
+
+	cfg := newFileConfig(opts)
+	s := &compressedFileSyncer{
+		file:          newFileLogger(path, cfg),
+		compress:      compress,
+		blockSize:     cfg.blockSize,
+		flushInterval: cfg.flushInterval,
+		done:          make(chan struct{}),
+	}
+
+	s.wg.Add(1)
+
+	go s.run()
+
+	return s, nil
+}
+
+// WithCompressedFileOutput writes entries to the file at path, compressed using the
+// provided codec, instead of stdout. See NewCompressedFileSyncer.
+func WithCompressedFileOutput(path string, codec Codec, opts ...FileOption) LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl == nil {
+			return
+		}
+
+		ws, err := NewCompressedFileSyncer(path, codec, opts...)
+		if err != nil {
+			tl.reportError(fmt.Errorf("failed to create compressed file output: %w", err))
+
+			return
+		}
+
+		tl.output = ws
+		tl.addCloser(ws)
+	}
+}
+
+func (s *compressedFileSyncer) run() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			_ = s.Sync()
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// Write buffers p, compressing the buffered entries once they reach the block size.
+func (s *compressedFileSyncer) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.buf.Write(p)
+	if s.buf.Len() < s.blockSize {
+		return len(p), nil
+	}
+
+	if err := s.flush(); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// Sync compresses the buffered entries and writes them to the file.
+func (s *compressedFileSyncer) Sync() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.flush()
+}
+
+// Close stops the background flush, writes the buffered entries and closes the file.
+func (s *compressedFileSyncer) Close() error {
+	s.once.Do(func() {
+		close(s.done)
+	})
+
+	s.wg.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.flush()
+	if closeErr := s.file.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// flush compresses the buffered entries into a block and writes it to the file. It
+// must be called with the lock held.
+func (s *compressedFileSyncer) flush() error {
+	if s.buf.Len() == 0 {
+		return nil
+	}
+
+	block, err := s.compress(s.buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("failed to compress entries: %w", err)
+	}
+
+	s.buf.Reset()
+
+	if _, err := s.file.Write(block); err != nil {
+		return fmt.Errorf("failed to write compressed entries: %w", err)
+	}
+
+	return nil
+}
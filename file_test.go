@@ -0,0 +1,199 @@
+package tracelog
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+// decompressFile decodes the blocks written by the compressed file WriteSyncer.
+func decompressFile(t *testing.T, path string, codec Codec) string {
+	t.Helper()
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open file: %v", err)
+	}
+	defer f.Close()
+
+	var r io.Reader
+	switch codec {
+	case CodecSnappy:
+		r = snappy.NewReader(f)
+	case CodecZstd:
+		dec, err := zstd.NewReader(f)
+		if err != nil {
+			t.Fatalf("failed to create zstd decoder: %v", err)
+		}
+		defer dec.Close()
+
+		r = dec
+	}
+
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("failed to decompress file: %v", err)
+	}
+
+	return string(out)
+}
+
+// fileSize returns the size of the file at path, or zero when it does not exist.
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+
+	return info.Size()
+}
+
+func TestCompressedFileSyncerBuffersBlocks(t *testing.T) {
+	for _, codec := range []Codec{CodecSnappy, CodecZstd} {
+		codec := codec
+
+		t.Run(map[Codec]string{CodecSnappy: "snappy", CodecZstd: "zstd"}[codec], func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "entries.log")
+
+			ws, err := NewCompressedFileSyncer(path, codec, WithCompressedFlushInterval(time.Hour))
+			if err != nil {
+				t.Fatalf("failed to create compressed file syncer: %v", err)
+			}
+			defer ws.(io.Closer).Close()
+
+			var want strings.Builder
+			for i := 0; i < 10; i++ {
+				entry := `{"msg":"entry","traceID":"0102"}` + "\n"
+				want.WriteString(entry)
+
+				if _, err := ws.Write([]byte(entry)); err != nil {
+					t.Fatalf("failed to write entry: %v", err)
+				}
+			}
+
+			if n := fileSize(path); n != 0 {
+				t.Errorf("wrote %d bytes before the block was full", n)
+			}
+
+			if err := ws.Sync(); err != nil {
+				t.Fatalf("failed to sync: %v", err)
+			}
+
+			if got := decompressFile(t, path, codec); got != want.String() {
+				t.Errorf("decompressed %q, want %q", got, want.String())
+			}
+		})
+	}
+}
+
+func TestCompressedFileSyncerFlushes(t *testing.T) {
+	t.Run("block size", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "entries.log")
+
+		ws, err := NewCompressedFileSyncer(path, CodecZstd, WithCompressedBlockSize(16), WithCompressedFlushInterval(time.Hour))
+		if err != nil {
+			t.Fatalf("failed to create compressed file syncer: %v", err)
+		}
+		defer ws.(io.Closer).Close()
+
+		if _, err := ws.Write(bytes.Repeat([]byte("x"), 16)); err != nil {
+			t.Fatalf("failed to write entry: %v", err)
+		}
+
+		if fileSize(path) == 0 {
+			t.Error("full block was not written")
+		}
+	})
+
+	t.Run("interval", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "entries.log")
+
+		ws, err := NewCompressedFileSyncer(path, CodecZstd, WithCompressedFlushInterval(10*time.Millisecond))
+		if err != nil {
+			t.Fatalf("failed to create compressed file syncer: %v", err)
+		}
+		defer ws.(io.Closer).Close()
+
+		if _, err := ws.Write([]byte("entry\n")); err != nil {
+			t.Fatalf("failed to write entry: %v", err)
+		}
+
+		deadline := time.Now().Add(5 * time.Second)
+		for fileSize(path) == 0 {
+			if time.Now().After(deadline) {
+				t.Fatal("buffered entries were not written once the interval elapsed")
+			}
+
+			time.Sleep(10 * time.Millisecond)
+		}
+	})
+
+	t.Run("close", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "entries.log")
+
+		ws, err := NewCompressedFileSyncer(path, CodecZstd, WithCompressedFlushInterval(time.Hour))
+		if err != nil {
+			t.Fatalf("failed to create compressed file syncer: %v", err)
+		}
+
+		if _, err := ws.Write([]byte("entry\n")); err != nil {
+			t.Fatalf("failed to write entry: %v", err)
+		}
+
+		if err := ws.(io.Closer).Close(); err != nil {
+			t.Fatalf("failed to close: %v", err)
+		}
+
+		if got := decompressFile(t, path, CodecZstd); got != "entry\n" {
+			t.Errorf("decompressed %q, want %q", got, "entry\n")
+		}
+	})
+}
+
+func TestWithRollingFileOutputClosesFile(t *testing.T) {
+	if _, err := os.ReadDir("/proc/self/fd"); err != nil {
+		t.Skip("open file descriptors cannot be listed")
+	}
+
+	path := filepath.Join(t.TempDir(), "app.log")
+	tl, _ := newBufferedLogger(WithRollingFileOutput(path))
+
+	tl.Info("hello")
+
+	if !isOpen(t, path) {
+		t.Fatal("file is not open after logging")
+	}
+
+	if err := tl.Close(); err != nil {
+		t.Fatalf("failed to close logger: %v", err)
+	}
+
+	if isOpen(t, path) {
+		t.Error("file is still open after Close")
+	}
+}
+
+// isOpen reports whether the process has a file descriptor open for the file at path.
+func isOpen(t *testing.T, path string) bool {
+	t.Helper()
+
+	fds, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		t.Fatalf("failed to list open file descriptors: %v", err)
+	}
+
+	for _, fd := range fds {
+		if target, err := os.Readlink(filepath.Join("/proc/self/fd", fd.Name())); err == nil && target == path {
+			return true
+		}
+	}
+
+	return false
+}
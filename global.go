@@ -0,0 +1,37 @@
+package tracelog
+
+import (
+	"fmt"
+	"log"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Global returns the base logger, suitable for registering as zap's global logger.
+// Entries written using the base logger are not associated with a `context.Context`,
+// so they are not correlated with a span unless the TraceLogger's context has been set,
+// in which case every entry is associated with that span.
+func (tl *TraceLogger) Global() *zap.Logger {
+	return tl.base.WithOptions(zap.AddCallerSkip(-internalCallerSkip))
+}
+
+// ReplaceGlobals installs the base logger of the TraceLogger as zap's global logger, used
+// by `zap.L()` and `zap.S()`, returning a function restoring the previous globals. Global
+// calls have no per-request context, so use the TraceLogger where trace correlation
+// is required.
+func ReplaceGlobals(tl *TraceLogger) func() {
+	return zap.ReplaceGlobals(tl.Global())
+}
+
+// StdLogger returns a standard library `log.Logger` writing to the base logger at the
+// provided level, for libraries which only accept a `*log.Logger`. Like Global, its
+// entries are not associated with a `context.Context`.
+func (tl *TraceLogger) StdLogger(level zapcore.Level) (*log.Logger, error) {
+	lg, err := zap.NewStdLogAt(tl.Global(), level)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create standard logger: %w", err)
+	}
+
+	return lg, nil
+}
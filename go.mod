@@ -1,17 +1,30 @@
 module github.com/ninnemana/tracelog
 
-go 1.17
+go 1.21
 
 require (
+	github.com/go-chi/chi/v5 v5.0.11
+	github.com/go-kit/log v0.2.1
+	github.com/golang/snappy v0.0.4
+	github.com/klauspost/compress v1.17.4
 	go.opentelemetry.io/otel v1.0.1
 	go.opentelemetry.io/otel/exporters/stdout/stdouttrace v1.0.1
 	go.opentelemetry.io/otel/sdk v1.0.1
 	go.opentelemetry.io/otel/trace v1.0.1
-	go.uber.org/zap v1.19.1
+	go.uber.org/multierr v1.6.0
+	go.uber.org/zap v1.24.0
+	golang.org/x/time v0.5.0
+	google.golang.org/grpc v1.58.3
+	gopkg.in/natefinch/lumberjack.v2 v2.2.1
 )
 
 require (
+	github.com/go-logfmt/logfmt v0.5.1 // indirect
+	github.com/golang/protobuf v1.5.3 // indirect
 	go.uber.org/atomic v1.7.0 // indirect
-	go.uber.org/multierr v1.6.0 // indirect
-	golang.org/x/sys v0.0.0-20210611083646-a4fc73990273 // indirect
+	golang.org/x/net v0.12.0 // indirect
+	golang.org/x/sys v0.10.0 // indirect
+	golang.org/x/text v0.11.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20230711160842-782d3b101e98 // indirect
+	google.golang.org/protobuf v1.31.0 // indirect
 )
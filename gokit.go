@@ -0,0 +1,150 @@
+package tracelog
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	gokitlog "github.com/go-kit/log"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	gokitLevelKey   = "level"
+	gokitMessageKey = "msg"
+)
+
+// GoKitLogger adapts the TraceLogger to the go-kit `log.Logger` interface. Key/value
+// pairs are converted to zap fields and dispatched to the level found under the
+// "level" key, defaulting to Info when no level is provided.
+func GoKitLogger(tl *TraceLogger) gokitlog.Logger {
+	return &gokitAdapter{tl: tl.WithCallerSkip(1)}
+}
+
+type gokitAdapter struct {
+	tl *TraceLogger
+}
+
+// Log implements the go-kit `log.Logger` interface.
+func (g *gokitAdapter) Log(keyvals ...interface{}) error {
+	if len(keyvals)%2 != 0 {
+		keyvals = append(keyvals, gokitlog.ErrMissingValue)
+	}
+
+	var (
+		msg   string
+		level = zapcore.InfoLevel
+		args  = make([]interface{}, 0, len(keyvals)/2)
+	)
+
+	for i := 0; i < len(keyvals); i += 2 {
+		key := fmt.Sprint(keyvals[i])
+		val := keyvals[i+1]
+
+		switch key {
+		case gokitLevelKey:
+			var lvl zapcore.Level
+			if err := lvl.UnmarshalText([]byte(fmt.Sprint(val))); err == nil {
+				level = lvl
+			}
+		case gokitMessageKey:
+			msg = fmt.Sprint(val)
+		default:
+			args = append(args, zap.Any(key, val))
+		}
+	}
+
+	switch level {
+	case zapcore.DebugLevel:
+		g.tl.Debug(msg, args...)
+	case zapcore.InfoLevel:
+		g.tl.Info(msg, args...)
+	case zapcore.WarnLevel:
+		g.tl.Warn(msg, args...)
+	case zapcore.ErrorLevel:
+		g.tl.Error(msg, args...)
+	case zapcore.DPanicLevel:
+		g.tl.DPanic(msg, args...)
+	case zapcore.PanicLevel:
+		g.tl.Panic(msg, args...)
+	case zapcore.FatalLevel:
+		g.tl.Fatal(msg, args...)
+	}
+
+	return nil
+}
+
+// FromGoKit creates a TraceLogger that writes its entries to the provided go-kit
+// `log.Logger`, associated with the provided `context.Context`.
+func FromGoKit(l gokitlog.Logger, ctx context.Context) *TraceLogger {
+	core := &gokitCore{
+		LevelEnabler: zapcore.DebugLevel,
+		logger:       l,
+	}
+
+	return NewLogger(WithLogger(zap.New(core))).SetContext(ctx)
+}
+
+// gokitCore is a zapcore.Core that forwards entries to a go-kit `log.Logger`.
+type gokitCore struct {
+	zapcore.LevelEnabler
+	logger gokitlog.Logger
+	fields []zapcore.Field
+}
+
+func (c *gokitCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.fields = make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	clone.fields = append(clone.fields, c.fields...)
+	clone.fields = append(clone.fields, fields...)
+
+	return &clone
+}
+
+func (c *gokitCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+
+	return ce
+}
+
+func (c *gokitCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range c.fields {
+		f.AddTo(enc)
+	}
+
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+
+	keyvals := make([]interface{}, 0, 4+len(enc.Fields)*2)
+	keyvals = append(keyvals, gokitLevelKey, ent.Level.String(), gokitMessageKey, ent.Message)
+
+	if ent.LoggerName != "" {
+		keyvals = append(keyvals, "logger", ent.LoggerName)
+	}
+
+	keys := make([]string, 0, len(enc.Fields))
+	for k := range enc.Fields {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		keyvals = append(keyvals, k, enc.Fields[k])
+	}
+
+	if err := c.logger.Log(keyvals...); err != nil {
+		return fmt.Errorf("failed to write to go-kit logger: %w", err)
+	}
+
+	return nil
+}
+
+func (c *gokitCore) Sync() error {
+	return nil
+}
@@ -0,0 +1,135 @@
+package tracelog
+
+import (
+	"fmt"
+	"testing"
+
+	gokitlog "github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestGoKitLoggerPairsKeyvals(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	lg := GoKitLogger(NewLogger(WithLogger(zap.New(core))))
+
+	if err := lg.Log("msg", "hello", 42, "answer", "dangling"); err != nil {
+		t.Fatalf("failed to log: %v", err)
+	}
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+
+	if entries[0].Message != "hello" {
+		t.Errorf("message = %q, want hello", entries[0].Message)
+	}
+
+	fields := entries[0].ContextMap()
+	if fields["42"] != "answer" {
+		t.Errorf("fields = %v, want the non-string key formatted", fields)
+	}
+
+	if fields["dangling"] != gokitlog.ErrMissingValue.Error() {
+		t.Errorf("fields = %v, want the odd key paired with ErrMissingValue", fields)
+	}
+}
+
+func TestGoKitLoggerMapsLevels(t *testing.T) {
+	tests := []struct {
+		name  string
+		level interface{}
+		want  zapcore.Level
+	}{
+		{name: "debug", level: "debug", want: zapcore.DebugLevel},
+		{name: "warn", level: "warn", want: zapcore.WarnLevel},
+		{name: "go-kit value", level: level.ErrorValue(), want: zapcore.ErrorLevel},
+		{name: "unknown", level: "unknown", want: zapcore.InfoLevel},
+		{name: "missing", want: zapcore.InfoLevel},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zapcore.DebugLevel)
+			lg := GoKitLogger(NewLogger(WithLogger(zap.New(core))))
+
+			keyvals := []interface{}{"msg", "hello"}
+			if tt.level != nil {
+				keyvals = append(keyvals, "level", tt.level)
+			}
+
+			if err := lg.Log(keyvals...); err != nil {
+				t.Fatalf("failed to log: %v", err)
+			}
+
+			entries := logs.All()
+			if len(entries) != 1 {
+				t.Fatalf("got %d entries, want 1", len(entries))
+			}
+
+			if entries[0].Level != tt.want {
+				t.Errorf("level = %s, want %s", entries[0].Level, tt.want)
+			}
+
+			if _, ok := entries[0].ContextMap()["level"]; ok {
+				t.Error("level was logged as a field")
+			}
+		})
+	}
+}
+
+func TestFromGoKitPropagatesContext(t *testing.T) {
+	var keyvals []interface{}
+	gk := gokitlog.LoggerFunc(func(kv ...interface{}) error {
+		keyvals = kv
+
+		return nil
+	})
+
+	ctx := contextWithSpan(1, 2)
+	tl := FromGoKit(gk, ctx)
+
+	if tl.Context() != ctx {
+		t.Error("logger is not associated with the provided context")
+	}
+
+	tl.With(zap.String("component", "worker")).Warn("hello", zap.Int("attempt", 3))
+
+	got := map[string]interface{}{}
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		got[fmt.Sprint(keyvals[i])] = keyvals[i+1]
+	}
+
+	sc := spanContext(1, 2)
+	want := map[string]interface{}{
+		"level":                   "warn",
+		"msg":                     "hello",
+		"component":               "worker",
+		"attempt":                 int64(3),
+		DefaultFieldNames.TraceID: sc.TraceID().String(),
+		DefaultFieldNames.SpanID:  sc.SpanID().String(),
+	}
+
+	for key, val := range want {
+		if got[key] != val {
+			t.Errorf("%s = %v, want %v in %v", key, got[key], val, keyvals)
+		}
+	}
+
+	other := spanContext(3, 4)
+	tl.InfoCtx(contextWithSpan(3, 4), "rebound")
+
+	got = map[string]interface{}{}
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		got[fmt.Sprint(keyvals[i])] = keyvals[i+1]
+	}
+
+	if got[DefaultFieldNames.SpanID] != other.SpanID().String() {
+		t.Errorf("spanID = %v, want the span of the call's context %s", got[DefaultFieldNames.SpanID], other.SpanID())
+	}
+}
@@ -0,0 +1,53 @@
+package tracelog
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/metadata"
+)
+
+// FromGRPCContext associates the `context.Context` of a gRPC server handler with the
+// logger. When the context does not already contain a valid span, such as one started
+// by an interceptor, the trace context is extracted from the incoming gRPC metadata
+// using the configured propagator.
+func (tl *TraceLogger) FromGRPCContext(ctx context.Context) *TraceLogger {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if p := tl.textMapPropagator(); p != nil {
+				ctx = p.Extract(ctx, metadataCarrier(md))
+			}
+		}
+	}
+
+	// Rebind replaces the correlation fields of a logger already bound to a span.
+	return tl.Rebind(ctx)
+}
+
+// metadataCarrier adapts gRPC metadata to the `propagation.TextMapCarrier` interface.
+// Metadata keys are lowercase, which MD handles on Get and Set.
+type metadataCarrier metadata.MD
+
+// Get returns the first value associated with the key.
+func (c metadataCarrier) Get(key string) string {
+	if vals := metadata.MD(c).Get(key); len(vals) > 0 {
+		return vals[0]
+	}
+
+	return ""
+}
+
+// Set sets the value associated with the key.
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+// Keys lists the keys stored in the carrier.
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+
+	return keys
+}
@@ -0,0 +1,25 @@
+package tracelog
+
+import (
+	"context"
+	"testing"
+
+	"go.opentelemetry.io/otel/propagation"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestFromGRPCContextReplacesCorrelationFields(t *testing.T) {
+	tl, buf := newBufferedLogger(WithPropagator(propagation.TraceContext{}))
+
+	md := metadata.MD{}
+	propagation.TraceContext{}.Inject(contextWithSpan(2, 2), metadataCarrier(md))
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+
+	tl.SetContext(contextWithSpan(1, 1)).FromGRPCContext(ctx).Info("handled")
+
+	assertUniqueKeys(t, buf.Lines()[0], correlationKeys...)
+
+	if got, want := buf.Entries(t)[0]["traceID"], spanContext(2, 2).TraceID().String(); got != want {
+		t.Errorf("traceID = %v, want %s", got, want)
+	}
+}
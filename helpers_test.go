@@ -0,0 +1,122 @@
+package tracelog
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// syncBuffer is a goroutine safe `zapcore.WriteSyncer` collecting the encoded entries.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) Sync() error {
+	return nil
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+// Lines returns the encoded entries written so far.
+func (b *syncBuffer) Lines() []string {
+	return strings.Split(strings.TrimSpace(b.String()), "\n")
+}
+
+// Entries decodes the JSON entries written so far.
+func (b *syncBuffer) Entries(t testing.TB) []map[string]interface{} {
+	t.Helper()
+
+	var entries []map[string]interface{}
+	for _, line := range b.Lines() {
+		if line == "" {
+			continue
+		}
+
+		var entry map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("failed to decode entry %q: %v", line, err)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+// withOutput writes the entries of the base logger to ws.
+func withOutput(ws *syncBuffer) LoggerOption {
+	return func(tl *TraceLogger) {
+		tl.output = ws
+	}
+}
+
+// newBufferedLogger creates a logger writing JSON entries to the returned buffer.
+func newBufferedLogger(opts ...LoggerOption) (*TraceLogger, *syncBuffer) {
+	buf := &syncBuffer{}
+
+	return NewLogger(append([]LoggerOption{withOutput(buf)}, opts...)...), buf
+}
+
+// useSpanRecorder installs a recording tracer provider as the global provider for the
+// duration of the test, returning the recorder of ended spans.
+func useSpanRecorder(t testing.TB) *tracetest.SpanRecorder {
+	t.Helper()
+
+	rec := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prev)
+	})
+
+	return rec
+}
+
+// spanContext returns a valid, sampled span context with the provided IDs.
+func spanContext(traceID, spanID byte) trace.SpanContext {
+	return trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{traceID},
+		SpanID:     trace.SpanID{spanID},
+		TraceFlags: trace.FlagsSampled,
+	})
+}
+
+// contextWithSpan returns a context containing a remote span with the provided IDs.
+func contextWithSpan(traceID, spanID byte) context.Context {
+	return trace.ContextWithSpanContext(context.Background(), spanContext(traceID, spanID))
+}
+
+// assertUniqueKeys fails the test when any of the keys appears more than once in a line.
+func assertUniqueKeys(t testing.TB, line string, keys ...string) {
+	t.Helper()
+
+	for _, key := range keys {
+		if n := strings.Count(line, `"`+key+`":`); n > 1 {
+			t.Errorf("key %q appears %d times in %s", key, n, line)
+		}
+	}
+}
+
+// correlationKeys are the keys of the default trace correlation fields.
+var correlationKeys = []string{"traceID", "dd.traceID", "spanID", "dd.spanID"}
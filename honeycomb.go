@@ -0,0 +1,194 @@
+package tracelog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const honeycombAPIHost = "https://api.honeycomb.io"
+
+// honeycombFieldNames maps field keys emitted by the TraceLogger to the fields
+// Honeycomb uses to assemble traces. Keys mapped to an empty string are dropped.
+var honeycombFieldNames = map[string]string{
+	"traceID":      "trace.trace_id",
+	"spanID":       "trace.span_id",
+	"parentSpanID": "trace.parent_id",
+	"dd.traceID":   "",
+	"dd.spanID":    "",
+}
+
+type honeycombConfig struct {
+	sampleRate   int
+	batchTimeout time.Duration
+	client       *http.Client
+}
+
+// HoneycombOption configures the Honeycomb WriteSyncer.
+type HoneycombOption func(*honeycombConfig)
+
+// WithSampleRate only sends one in every n events, recording the rate on each
+// event so Honeycomb can re-weight the results.
+func WithSampleRate(n int) HoneycombOption {
+	return func(cfg *honeycombConfig) {
+		if cfg != nil && n > 0 {
+			cfg.sampleRate = n
+		}
+	}
+}
+
+// WithBatchTimeout sets the maximum amount of time events are buffered before being
+// sent. Defaults to one second.
+func WithBatchTimeout(d time.Duration) HoneycombOption {
+	return func(cfg *honeycombConfig) {
+		if cfg != nil {
+			cfg.batchTimeout = d
+		}
+	}
+}
+
+type honeycombEvent struct {
+	Time       time.Time              `json:"time"`
+	SampleRate int                    `json:"samplerate,omitempty"`
+	Data       map[string]interface{} `json:"data"`
+}
+
+// NewHoneycombWriteSyncer creates a `zapcore.WriteSyncer` that buffers JSON encoded
+// entries and sends them as events to the provided Honeycomb dataset, using the
+// batch events API. Events are timestamped using the `ts` field of the entry. Full
+// batches are sent in the background, and Sync waits for the buffered entries to be
+// sent. The returned WriteSyncer implements `io.Closer`.
+func NewHoneycombWriteSyncer(apiKey, dataset string, opts ...HoneycombOption) (zapcore.WriteSyncer, error) {
+	if apiKey == "" {
+		return nil, errors.New("honeycomb API key is required")
+	}
+
+	if dataset == "" {
+		return nil, errors.New("honeycomb dataset is required")
+	}
+
+	cfg := &honeycombConfig{
+		sampleRate: 1,
+		client:     &http.Client{Timeout: 10 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	endpoint := fmt.Sprintf("%s/1/batch/%s", honeycombAPIHost, url.PathEscape(dataset))
+
+	send := func(entries [][]byte) error {
+		events := make([]honeycombEvent, 0, len(entries))
+		for _, entry := range entries {
+			if cfg.sampleRate > 1 && rand.Intn(cfg.sampleRate) != 0 { //nolint:gosec
+				continue
+			}
+
+			ts, data := honeycombData(entry)
+			events = append(events, honeycombEvent{
+				Time:       ts,
+				SampleRate: cfg.sampleRate,
+				Data:       data,
+			})
+		}
+
+		if len(events) == 0 {
+			return nil
+		}
+
+		body, err := json.Marshal(events)
+		if err != nil {
+			return fmt.Errorf("failed to encode Honeycomb events: %w", err)
+		}
+
+		req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("failed to create Honeycomb request: %w", err)
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Honeycomb-Team", apiKey)
+
+		return sendRequest(cfg.client, req)
+	}
+
+	return newBatchSyncer(defaultBatchSize, cfg.batchTimeout, send), nil
+}
+
+// WithHoneycombOutput sends entries to the provided Honeycomb dataset instead of stdout.
+func WithHoneycombOutput(apiKey, dataset string, opts ...HoneycombOption) LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl == nil {
+			return
+		}
+
+		ws, err := NewHoneycombWriteSyncer(apiKey, dataset, opts...)
+		if err != nil {
+			tl.reportError(fmt.Errorf("failed to create Honeycomb output: %w", err))
+
+			return
+		}
+
+		tl.output = ws
+		tl.addCloser(ws)
+	}
+}
+
+// honeycombData decodes a JSON encoded entry into a flat Honeycomb event along with the
+// time of the entry, falling back to sending the raw entry as the message at the current
+// time when it isn't JSON.
+func honeycombData(entry []byte) (time.Time, map[string]interface{}) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(entry, &raw); err != nil {
+		return time.Now(), map[string]interface{}{
+			"message": string(bytes.TrimSpace(entry)),
+		}
+	}
+
+	ts := time.Now()
+	if v, ok := raw["ts"]; ok {
+		if parsed, err := parseTimestamp(v); err == nil {
+			ts = parsed
+			delete(raw, "ts")
+		}
+	}
+
+	data := make(map[string]interface{}, len(raw))
+	for key, val := range raw {
+		name, ok := honeycombFieldNames[key]
+		switch {
+		case !ok:
+			data[key] = val
+		case name != "":
+			data[name] = val
+		}
+	}
+
+	return ts, data
+}
+
+// sendRequest executes the request, treating non-2xx responses as errors.
+func sendRequest(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request to %s: %w", req.URL.Host, err)
+	}
+
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected response from %s: %s", req.URL.Host, resp.Status)
+	}
+
+	return nil
+}
@@ -0,0 +1,33 @@
+package tracelog
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHoneycombDataUsesEntryTime(t *testing.T) {
+	ts, data := honeycombData([]byte(`{"level":"info","ts":1700000000.5,"msg":"hello","traceID":"0102","dd.traceID":"1"}`))
+
+	if want := time.Unix(1700000000, 5e8); !ts.Equal(want) {
+		t.Errorf("time = %s, want %s", ts, want)
+	}
+
+	if _, ok := data["ts"]; ok {
+		t.Error("event data contains the ts field")
+	}
+
+	if _, ok := data["dd.traceID"]; ok {
+		t.Error("event data contains the dd.traceID field")
+	}
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		t.Fatalf("failed to encode event data: %v", err)
+	}
+
+	if !strings.Contains(string(encoded), `"trace.trace_id":"0102"`) {
+		t.Errorf("event data %s does not contain the Honeycomb trace ID", encoded)
+	}
+}
@@ -0,0 +1,75 @@
+package tracelog
+
+import (
+	"encoding/binary"
+	"strconv"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TraceIDFormat controls how trace IDs are written in the correlation fields.
+type TraceIDFormat int
+
+const (
+	// TraceIDHex writes the 128-bit trace ID as 32 hex characters. This is the default.
+	TraceIDHex TraceIDFormat = iota
+	// TraceIDDecimal64 writes the lower 64 bits of the trace ID as a decimal, as used by
+	// Datadog for log correlation.
+	TraceIDDecimal64
+	// TraceIDDecimal128 writes the upper and lower 64 bits of the trace ID as decimals
+	// separated by a hyphen.
+	TraceIDDecimal128
+)
+
+// SpanIDFormat controls how span IDs are written in the correlation fields.
+type SpanIDFormat int
+
+const (
+	// SpanIDHex writes the span ID as 16 hex characters. This is the default.
+	SpanIDHex SpanIDFormat = iota
+	// SpanIDDecimal writes the span ID as a decimal, as used by Datadog.
+	SpanIDDecimal
+)
+
+// WithTraceIDFormat sets the format of the trace ID in the `traceID` and `dd.traceID`
+// fields, or the keys set using WithFieldNames. Other fields derived from the trace ID,
+// such as the short trace ID and trace URL, are always hex.
+func WithTraceIDFormat(format TraceIDFormat) LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl != nil {
+			tl.traceIDFormat = format
+		}
+	}
+}
+
+// WithSpanIDFormat sets the format of the span ID in the `spanID` and `dd.spanID`
+// fields, or the keys set using WithFieldNames.
+func WithSpanIDFormat(format SpanIDFormat) LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl != nil {
+			tl.spanIDFormat = format
+		}
+	}
+}
+
+// String formats the trace ID.
+func (f TraceIDFormat) String(id trace.TraceID) string {
+	switch f {
+	case TraceIDDecimal64:
+		return strconv.FormatUint(binary.BigEndian.Uint64(id[8:]), 10)
+	case TraceIDDecimal128:
+		return strconv.FormatUint(binary.BigEndian.Uint64(id[:8]), 10) + "-" +
+			strconv.FormatUint(binary.BigEndian.Uint64(id[8:]), 10)
+	default:
+		return id.String()
+	}
+}
+
+// String formats the span ID.
+func (f SpanIDFormat) String(id trace.SpanID) string {
+	if f == SpanIDDecimal {
+		return strconv.FormatUint(binary.BigEndian.Uint64(id[:]), 10)
+	}
+
+	return id.String()
+}
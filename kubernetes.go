@@ -0,0 +1,68 @@
+package tracelog
+
+import (
+	"os"
+
+	"go.uber.org/zap"
+)
+
+// KubernetesMetadataConfig names the environment variables the pod metadata is read
+// from, as populated using the Kubernetes Downward API. Empty names are not read.
+type KubernetesMetadataConfig struct {
+	PodNameEnv        string
+	NamespaceEnv      string
+	NodeNameEnv       string
+	PodIPEnv          string
+	ServiceAccountEnv string
+}
+
+// DefaultKubernetesMetadataConfig reads the POD_NAME, POD_NAMESPACE, NODE_NAME, POD_IP
+// and SERVICE_ACCOUNT environment variables.
+var DefaultKubernetesMetadataConfig = KubernetesMetadataConfig{
+	PodNameEnv:        "POD_NAME",
+	NamespaceEnv:      "POD_NAMESPACE",
+	NodeNameEnv:       "NODE_NAME",
+	PodIPEnv:          "POD_IP",
+	ServiceAccountEnv: "SERVICE_ACCOUNT",
+}
+
+// WithKubernetesPodMetadata adds the pod metadata found in the environment variables of
+// DefaultKubernetesMetadataConfig to every entry. See WithKubernetesPodMetadataConfig.
+func WithKubernetesPodMetadata() LoggerOption {
+	return WithKubernetesPodMetadataConfig(DefaultKubernetesMetadataConfig)
+}
+
+// WithKubernetesPodMetadataConfig adds the pod metadata found in the configured
+// environment variables to every entry, using the OpenTelemetry Kubernetes semantic
+// conventions such as `k8s.pod.name` and `k8s.namespace.name`. Variables which are
+// unset or empty are skipped.
+func WithKubernetesPodMetadataConfig(cfg KubernetesMetadataConfig) LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl == nil {
+			return
+		}
+
+		var fields []zap.Field
+		for _, m := range []struct {
+			key, env string
+		}{
+			{key: "k8s.pod.name", env: cfg.PodNameEnv},
+			{key: "k8s.namespace.name", env: cfg.NamespaceEnv},
+			{key: "k8s.node.name", env: cfg.NodeNameEnv},
+			{key: "k8s.pod.ip", env: cfg.PodIPEnv},
+			{key: "k8s.pod.service_account.name", env: cfg.ServiceAccountEnv},
+		} {
+			if m.env == "" {
+				continue
+			}
+
+			if v := os.Getenv(m.env); v != "" {
+				fields = append(fields, zap.String(m.key, v))
+			}
+		}
+
+		if len(fields) > 0 {
+			tl.zapOpts = append(tl.zapOpts, zap.Fields(fields...))
+		}
+	}
+}
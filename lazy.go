@@ -0,0 +1,18 @@
+package tracelog
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Lazy returns a field which is marshaled using fn when the entry is written, so fn is
+// not called for entries at disabled levels. It is shorthand for `zap.Object` with a
+// `zapcore.ObjectMarshalerFunc`.
+//
+//	tl.Debug("cache state", tracelog.Lazy("cache", func(enc zapcore.ObjectEncoder) error {
+//		enc.AddInt("size", cache.Len())
+//		return nil
+//	}))
+func Lazy(key string, fn func(zapcore.ObjectEncoder) error) zap.Field {
+	return zap.Object(key, zapcore.ObjectMarshalerFunc(fn))
+}
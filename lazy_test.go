@@ -0,0 +1,70 @@
+package tracelog
+
+import (
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// expensiveMarshaler counts its calls, standing in for serializing a large struct.
+func expensiveMarshaler(calls *int) func(zapcore.ObjectEncoder) error {
+	return func(enc zapcore.ObjectEncoder) error {
+		*calls++
+
+		var sb strings.Builder
+		for i := 0; i < 1000; i++ {
+			sb.WriteString("state")
+		}
+
+		enc.AddString("state", sb.String())
+
+		return nil
+	}
+}
+
+func TestLazyOnlyMarshalsWrittenEntries(t *testing.T) {
+	var calls int
+	tl, buf := newBufferedLogger()
+	lg := tl.SetContext(contextWithSpan(1, 2))
+
+	lg.Debug("disabled", Lazy("cache", expensiveMarshaler(&calls)))
+	if calls != 0 {
+		t.Errorf("marshaler called %d times for a disabled entry", calls)
+	}
+
+	lg.Info("enabled", Lazy("cache", expensiveMarshaler(&calls)))
+	if calls != 1 {
+		t.Errorf("marshaler called %d times for a written entry, want 1", calls)
+	}
+
+	if !strings.Contains(buf.String(), `"cache":{"state":"state`) {
+		t.Errorf("entry %s does not contain the lazy field", buf.String())
+	}
+}
+
+func BenchmarkLazy(b *testing.B) {
+	lg := newDiscardLogger().SetContext(contextWithSpan(1, 2))
+
+	for _, bm := range []struct {
+		name string
+		log  func(msg string, args ...interface{})
+	}{
+		{name: "disabled level", log: lg.Debug},
+		{name: "enabled level", log: lg.Info},
+	} {
+		bm := bm
+
+		b.Run(bm.name, func(b *testing.B) {
+			var calls int
+			field := Lazy("cache", expensiveMarshaler(&calls))
+
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				bm.log("cache state", field)
+			}
+
+			b.ReportMetric(float64(calls)/float64(b.N), "marshals/op")
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package tracelog
+
+import (
+	"go.uber.org/zap/zapcore"
+)
+
+// A NamedLevelEnabler decides whether entries are enabled based on the name of the
+// logger, as set using Named, in addition to the level. Enabled must report true
+// for a level if EnabledFor reports true for that level for any name.
+type NamedLevelEnabler interface {
+	zapcore.LevelEnabler
+	EnabledFor(name string, lvl zapcore.Level) bool
+}
+
+// WithLevelEnabler sets the logic deciding which entries are written by the base logger
+// built by the TraceLogger, replacing the default of InfoLevel. Provide a
+// `zap.AtomicLevel` to change the level at runtime, or a NamedLevelEnabler to enable
+// levels per named sub-logger. When provided multiple times, the last enabler is used.
+// The enabler is not applied to a base logger provided using WithLogger.
+func WithLevelEnabler(enabler zapcore.LevelEnabler) LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl != nil {
+			tl.level = enabler
+		}
+	}
+}
+
+// namedEnablerCore filters entries using the name of the logger that wrote them.
+type namedEnablerCore struct {
+	zapcore.Core
+	enabler NamedLevelEnabler
+}
+
+func (c *namedEnablerCore) With(fields []zapcore.Field) zapcore.Core {
+	return &namedEnablerCore{
+		Core:    c.Core.With(fields),
+		enabler: c.enabler,
+	}
+}
+
+func (c *namedEnablerCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.enabler.EnabledFor(ent.LoggerName, ent.Level) {
+		return ce
+	}
+
+	return c.Core.Check(ent, ce)
+}
+
+// newLevelCore creates a core for the provided encoder and output using the configured
+// level enabler.
+func (tl *TraceLogger) newLevelCore(enc zapcore.Encoder, out zapcore.WriteSyncer) zapcore.Core {
+	lvl := tl.levelEnabler()
+	core := zapcore.NewCore(enc, out, lvl)
+
+	if named, ok := lvl.(NamedLevelEnabler); ok {
+		return &namedEnablerCore{
+			Core:    core,
+			enabler: named,
+		}
+	}
+
+	return core
+}
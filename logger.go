@@ -3,26 +3,131 @@ package tracelog
 import (
 	"context"
 	"fmt"
+	"io"
 	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	"go.opentelemetry.io/otel/propagation"
-	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
 	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/multierr"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 // A TraceLogger wraps the base Logger functionality in logic to tag
 // and correlate OpenTelemtry data with the associated log entries.
+//
+// The log methods accept `zap.Field` arguments, which are logged, and
+// `attribute.KeyValue` arguments, which tag the span. `error` arguments are logged
+// using WithErrorFormatter and recorded on the span. A `zapcore.Level` argument
+// overrides the level of the method for that entry, with the last level taking
+// precedence when several are provided. Other arguments are ignored.
+//
+// Fields are only evaluated when the entry is written. Use Lazy, or `zap.Object` with
+// a `zapcore.ObjectMarshalerFunc`, for fields which are expensive to build, so the work
+// is skipped at disabled levels; values computed before the call are always computed.
+// Messages without arguments do not allocate. Arguments which are not pointers, such
+// as a `zap.Field`, are boxed by the call and allocate even at disabled levels, so check
+// Enabled first on hot paths.
 type TraceLogger struct {
 	base *zap.Logger
 	ctx  context.Context
+	name string
+
+	// verbose is the base logger without level filtering, used for contexts marked
+	// using ForceVerbose. It is only set when the base logger is built by the TraceLogger.
+	verbose *zap.Logger
+
+	// unbound and unboundVerbose are the base loggers without the span correlation
+	// fields, set once the logger is bound to a span and used by Rebind.
+	unbound        *zap.Logger
+	unboundVerbose *zap.Logger
+
+	fieldNames       FieldNames
+	traceIDFormat    TraceIDFormat
+	spanIDFormat     SpanIDFormat
+	shortTraceID     int
+	nestedTraceKey   string
+	traceURLTemplate string
+	minTagLevel      zapcore.Level
+
+	// spanStatusLevel is the level at or above which entries set the span status, when
+	// spanStatus is set using WithSpanStatusMinLevel.
+	spanStatus      bool
+	spanStatusLevel zapcore.Level
+	statusMapper    StatusMapper
+
+	countSpanLogs bool
+
+	// contextCores is set by the options installing cores which read the
+	// `context.Context` of an entry, so contextField is only added for them.
+	contextCores bool
+
+	// adaptiveLevel wraps the core of the base logger, but not the verbose logger, when
+	// set using WithAdaptiveLevel.
+	adaptiveLevel func(zapcore.Core) zapcore.Core
+
+	validateAttrs    bool
+	invalidAttrLevel zapcore.Level
+
+	contextFields    func(context.Context) []zap.Field
+	tagContextFields bool
+	errorFormatter   func(error) []zap.Field
+
+	// fieldNamespace groups the fields provided by callers, which are held in
+	// namespacedFields rather than added to the base logger.
+	fieldNamespace   string
+	namespacedFields []zap.Field
+
+	logCancellation bool
+	repanic         bool
+	propagator      propagation.TextMapPropagator
+	baggage         bool
+	queryParamKey   string
+
+	spanStartOptions []trace.SpanStartOption
+	semconvVersion   SemconvVersion
+
+	// encoder, output and level are used to build the base logger when one is not
+	// provided using WithLogger.
+	encoder       zapcore.Encoder
+	encoderConfig *zapcore.EncoderConfig
+	timeEncoder   zapcore.TimeEncoder
+	logfmt        bool
+	cloudLogging  bool
+	output        zapcore.WriteSyncer
+	level         zapcore.LevelEnabler
+
+	asyncBufferSize int
+	encryptionKey   []byte
+
+	// closers are the outputs created by the TraceLogger, closed in reverse order by Close.
+	closers []io.Closer
+
+	// teeOutputs receive entries in addition to the base logger.
+	teeOutputs []zapcore.WriteSyncer
+
+	// errorSink receives ErrorLevel entries and above once set using SetErrorSink.
+	errorSink *errorSink
+
+	// zapOpts are applied to the base logger once all options have been evaluated.
+	zapOpts []zap.Option
 }
 
 type LoggerOption func(*TraceLogger)
 
-// WithLogger sets the base logger to use in the TraceLogger.
+// internalCallerSkip is the number of frames added by the TraceLogger between the
+// caller and the base logger.
+const internalCallerSkip = 2
+
+// WithLogger sets the base logger to use in the TraceLogger. When no base logger is
+// provided, one is built from the configured encoder and output.
 func WithLogger(lg *zap.Logger) LoggerOption {
 	return func(tl *TraceLogger) {
 		if tl != nil {
@@ -31,130 +136,657 @@ func WithLogger(lg *zap.Logger) LoggerOption {
 	}
 }
 
+// FieldNames are the keys of the trace correlation fields. Fields with an empty key
+// are not emitted.
+type FieldNames struct {
+	TraceID   string
+	SpanID    string
+	DDTraceID string
+	DDSpanID  string
+}
+
+// DefaultFieldNames are the keys of the trace correlation fields used by default.
+var DefaultFieldNames = FieldNames{
+	TraceID:   "traceID",
+	SpanID:    "spanID",
+	DDTraceID: "dd.traceID",
+	DDSpanID:  "dd.spanID",
+}
+
+// WithFieldNames sets the keys of the trace correlation fields, replacing
+// DefaultFieldNames. Set a key to an empty string to omit that field. Output formats
+// such as WithECSOutput only rename the default keys.
+func WithFieldNames(names FieldNames) LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl != nil {
+			tl.fieldNames = names
+		}
+	}
+}
+
+// WithShortTraceID additionally emits a `tid` field containing the first `n` hex
+// characters of the trace ID, for quick correlation when scanning logs. The full
+// trace ID is still emitted.
+func WithShortTraceID(n int) LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl != nil {
+			tl.shortTraceID = n
+		}
+	}
+}
+
+// WithNestedTraceField emits the trace correlation as a single object under key,
+// containing the `trace_id`, `span_id`, `trace_flags` and `trace_state`, instead of
+// the flat `traceID` and `spanID` fields.
+func WithNestedTraceField(key string) LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl != nil {
+			tl.nestedTraceKey = key
+		}
+	}
+}
+
+// traceURLPlaceholder is substituted with the trace ID in the trace URL template.
+const traceURLPlaceholder = "{traceID}"
+
+// WithTraceURLTemplate adds a `traceURL` field linking to the trace in a tracing UI, built
+// by substituting the trace ID for the `{traceID}` placeholder, such as
+// `https://jaeger.example.com/trace/{traceID}`. The field is only added when the logger's
+// context contains a valid span. Templates without the placeholder are reported and ignored.
+func WithTraceURLTemplate(tmpl string) LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl == nil {
+			return
+		}
+
+		if !strings.Contains(tmpl, traceURLPlaceholder) {
+			tl.reportError(fmt.Errorf("trace URL template %q does not contain %s", tmpl, traceURLPlaceholder))
+			return
+		}
+
+		tl.traceURLTemplate = tmpl
+	}
+}
+
+// WithMinTagLevel only tags the span with attributes for entries at or above the
+// provided level. Entries below the level are still logged. Defaults to tagging
+// at all levels.
+func WithMinTagLevel(lvl zapcore.Level) LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl != nil {
+			tl.minTagLevel = lvl
+		}
+	}
+}
+
+// WithSpanStatusMinLevel sets the status of the span to error, described by the message,
+// for entries at or above the provided level, independently of WithMinTagLevel. By
+// default, entries do not change the span status.
+func WithSpanStatusMinLevel(lvl zapcore.Level) LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl != nil {
+			tl.spanStatus = true
+			tl.spanStatusLevel = lvl
+		}
+	}
+}
+
+// A StatusMapper returns the span status code and description for an entry at lvl with
+// the message msg. Returning `codes.Unset` leaves the span status unchanged.
+type StatusMapper func(lvl zapcore.Level, msg string) (codes.Code, string)
+
+// WithStatusMapper sets the status of the span for entries using mapper, in place of
+// setting it to error described by the message. When WithSpanStatusMinLevel is also
+// used, only entries at or above its level are mapped; otherwise every entry is.
+func WithStatusMapper(mapper StatusMapper) LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl != nil {
+			tl.statusMapper = mapper
+		}
+	}
+}
+
+// WithErrorOutput sets the destination for the base logger's internal errors, such as
+// failures to write entries. Defaults to stderr.
+func WithErrorOutput(ws zapcore.WriteSyncer) LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl != nil {
+			tl.zapOpts = append(tl.zapOpts, zap.ErrorOutput(ws))
+		}
+	}
+}
+
+// WithPropagator sets the `propagation.TextMapPropagator` used to extract and inject
+// trace context on HTTP requests. A nil propagator falls back to the global propagator.
+func WithPropagator(p propagation.TextMapPropagator) LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl != nil {
+			tl.propagator = p
+		}
+	}
+}
+
+// WithBaggagePropagation composes the configured propagator with `propagation.Baggage`,
+// so baggage members are extracted and injected alongside the trace context even when
+// the propagator does not include it.
+func WithBaggagePropagation() LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl != nil {
+			tl.baggage = true
+		}
+	}
+}
+
+// traceparentHeader is the W3C Trace Context header carrying the trace and span IDs.
+const traceparentHeader = "traceparent"
+
+// baggageHeader is the W3C Baggage header carrying the baggage members.
+const baggageHeader = "baggage"
+
+// WithQueryParamFallback extracts the trace context from the named query parameter,
+// formatted as a W3C `traceparent` value, when none is found in the request headers.
+// This supports proxies and platforms which strip the tracing headers. The query is
+// only parsed when the headers do not contain a trace context.
+func WithQueryParamFallback(paramKey string) LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl != nil {
+			tl.queryParamKey = paramKey
+		}
+	}
+}
+
+// WithCallerSkip increases the number of callers skipped by caller annotation, for use
+// when the TraceLogger is wrapped by helper functions. The skip is added to the frames
+// the TraceLogger itself accounts for, as well as any skip configured on the base
+// logger using `zap.AddCallerSkip`.
+func WithCallerSkip(n int) LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl != nil {
+			tl.zapOpts = append(tl.zapOpts, zap.AddCallerSkip(n))
+		}
+	}
+}
+
+// WithEncoderConfig sets the configuration of the JSON encoder used when the TraceLogger
+// builds the base logger. Defaults to zap's production encoder configuration. It has no
+// effect when an output format such as WithECSOutput is used.
+func WithEncoderConfig(cfg zapcore.EncoderConfig) LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl != nil {
+			tl.encoderConfig = &cfg
+		}
+	}
+}
+
+// WithTimeEncoder sets how timestamps are encoded by the JSON encoder used when the
+// TraceLogger builds the base logger, taking precedence over WithEncoderConfig.
+func WithTimeEncoder(enc zapcore.TimeEncoder) LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl != nil {
+			tl.timeEncoder = enc
+		}
+	}
+}
+
+// WithLogfmtEncoder encodes entries as logfmt `key=value` pairs, such as
+// `traceID=...`, instead of JSON when the TraceLogger builds the base logger. The
+// configuration from WithEncoderConfig and WithTimeEncoder is respected.
+func WithLogfmtEncoder() LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl != nil {
+			tl.encoder = nil
+			tl.logfmt = true
+		}
+	}
+}
+
+// WithoutCaller disables annotating entries with the caller, removing the `caller` key
+// from the output. This avoids the cost of resolving the caller on hot paths.
+func WithoutCaller() LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl != nil {
+			tl.zapOpts = append(tl.zapOpts, zap.WithCaller(false))
+		}
+	}
+}
+
+// WithHooks registers functions called with each entry written by the base logger,
+// including Fatal entries before the process exits.
+func WithHooks(hooks ...func(zapcore.Entry) error) LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl != nil {
+			tl.zapOpts = append(tl.zapOpts, zap.Hooks(hooks...))
+		}
+	}
+}
+
+// WithErrorFormatter sets how `error` arguments to the log methods are expanded into
+// fields, such as the message, type and cause, replacing the default of zap.Error. The
+// fields are also added as attributes of the error recorded on the span.
+func WithErrorFormatter(format func(error) []zap.Field) LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl != nil {
+			tl.errorFormatter = format
+		}
+	}
+}
+
+// WithFieldNamespace nests the fields provided to the log methods and With under the
+// provided key, such as `attributes`, matching the OTLP log data model. The message,
+// level and correlation fields, along with fields added by options, remain at the top
+// level so they can be indexed.
+func WithFieldNamespace(key string) LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl != nil {
+			tl.fieldNamespace = key
+		}
+	}
+}
+
+// WithFatalHook sets the hook run after Fatal entries are written, replacing the default
+// of calling `os.Exit(1)`. Tests should use `WithFatalHook(WriteThenGoexit)`, so Fatal
+// stops the test and marks it as failed without exiting the test binary.
+func WithFatalHook(hook zapcore.CheckWriteHook) LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl != nil {
+			tl.zapOpts = append(tl.zapOpts, zap.WithFatalHook(hook))
+		}
+	}
+}
+
+var (
+	// WriteThenNoop continues execution once a Fatal entry has been written. Zap replaces
+	// `zapcore.WriteThenNoop` with `os.Exit(1)` for Fatal entries, so this is a distinct hook.
+	WriteThenNoop zapcore.CheckWriteHook = noopHook{}
+
+	// WriteThenGoexit runs `runtime.Goexit` once a Fatal entry has been written, stopping
+	// the current goroutine while deferred calls still run.
+	WriteThenGoexit zapcore.CheckWriteHook = zapcore.WriteThenGoexit
+)
+
+// noopHook does nothing once an entry has been written.
+type noopHook struct{}
+
+func (noopHook) OnWrite(*zapcore.CheckedEntry, []zapcore.Field) {}
+
 // NewLogger instaniates a new instance our of logger.
 func NewLogger(opts ...LoggerOption) *TraceLogger {
-	tl := &TraceLogger{}
+	tl := &TraceLogger{
+		fieldNames:  DefaultFieldNames,
+		minTagLevel: zapcore.DebugLevel,
+	}
 	for _, opt := range opts {
 		opt(tl)
 	}
 
+	if tl.base == nil {
+		if tl.adaptiveLevel != nil && tl.level == nil {
+			tl.level = zapcore.DebugLevel
+		}
+
+		tl.base, tl.verbose = tl.newBase()
+	}
+
+	if len(tl.teeOutputs) > 0 {
+		tl.updateBase(func(lg *zap.Logger) *zap.Logger {
+			return lg.WithOptions(zap.WrapCore(tl.teeCore))
+		})
+	}
+
+	tl.errorSink = &errorSink{}
+	tl.updateBase(func(lg *zap.Logger) *zap.Logger {
+		return lg.WithOptions(zap.WrapCore(tl.errorSinkCore))
+	})
+
+	tl.updateBase(func(lg *zap.Logger) *zap.Logger {
+		return lg.WithOptions(zap.AddCallerSkip(internalCallerSkip)).WithOptions(tl.zapOpts...)
+	})
+
+	if tl.adaptiveLevel != nil {
+		tl.base = tl.base.WithOptions(zap.WrapCore(tl.adaptiveLevel))
+	}
+
 	return tl
 }
 
 // Named adds a sub-scope to the logger's name. See Logger.Named for details.
 func (tl *TraceLogger) Named(name string) *TraceLogger {
-	return &TraceLogger{
-		base: tl.base.Named(name),
-		ctx:  tl.ctx,
+	l := tl.clone()
+	l.updateBase(func(lg *zap.Logger) *zap.Logger {
+		return lg.Named(name)
+	})
+
+	switch {
+	case name == "":
+	case tl.name == "":
+		l.name = name
+	default:
+		l.name = tl.name + "." + name
 	}
+
+	return l
 }
 
-// SetContext associates the `context.Context` in use with the instance of our logger.
+// SetContext associates the `context.Context` in use with the instance of our logger. The
+// trace correlation fields are only added when the context contains a valid span. The
+// fields of a SpanLogContext carried by the context are added as they are when called.
 func (tl *TraceLogger) SetContext(ctx context.Context) *TraceLogger {
-	l := &TraceLogger{
-		base: tl.base,
-		ctx:  ctx,
+	l := tl.clone()
+	l.ctx = ctx
+
+	spanCtx := trace.SpanContextFromContext(ctx)
+	if spanCtx.IsValid() {
+		l.bindSpan(tl.traceFields(spanCtx)...)
 	}
 
-	span := trace.SpanFromContext(l.ctx)
-	if span == nil {
-		return tl
+	if fields := SpanContextFrom(ctx).Fields(); len(fields) > 0 {
+		l = l.With(fields...)
 	}
 
-	spanCtx := span.SpanContext()
+	if l.logCancellation && ctx != nil && ctx.Err() != nil {
+		l.LogContextCancellation(ctx)
+	}
 
-	return l.With(
-		zap.String("traceID", spanCtx.TraceID().String()),
-		zap.String("dd.traceID", spanCtx.TraceID().String()),
-		zap.String("spanID", spanCtx.SpanID().String()),
-		zap.String("dd.spanID", spanCtx.SpanID().String()),
-	)
+	return l
+}
+
+// Rebind associates ctx with the logger like SetContext, but replaces the trace
+// correlation fields of the span the logger is bound to instead of adding another set,
+// reusing the fields added since. It is intended for loops starting many short spans,
+// and removes the `parentSpanID` field added by StartSpan. The fields of a SpanLogContext
+// are not added again.
+func (tl *TraceLogger) Rebind(ctx context.Context) *TraceLogger {
+	if tl.unbound == nil {
+		return tl.SetContext(ctx)
+	}
+
+	l := tl.clone()
+	l.ctx = ctx
+	l.base, l.verbose = tl.unbound, tl.unboundVerbose
+
+	spanCtx := trace.SpanContextFromContext(ctx)
+	if spanCtx.IsValid() {
+		l.bindSpan(tl.traceFields(spanCtx)...)
+	}
+
+	if l.logCancellation && ctx != nil && ctx.Err() != nil {
+		l.LogContextCancellation(ctx)
+	}
+
+	return l
+}
+
+// bindSpan adds the span correlation fields to the base loggers, keeping the loggers
+// without them for Rebind. It modifies tl, so must only be called on a clone.
+func (tl *TraceLogger) bindSpan(fields ...zap.Field) {
+	if tl.unbound == nil {
+		tl.unbound, tl.unboundVerbose = tl.base, tl.verbose
+	}
+
+	tl.base = tl.base.With(fields...)
+	if tl.verbose != nil {
+		tl.verbose = tl.verbose.With(fields...)
+	}
+}
+
+// traceFields returns the correlation fields for the provided span context.
+func (tl *TraceLogger) traceFields(spanCtx trace.SpanContext) []zap.Field {
+	traceID := spanCtx.TraceID().String()
+	formattedTraceID := tl.traceIDFormat.String(spanCtx.TraceID())
+	spanID := tl.spanIDFormat.String(spanCtx.SpanID())
+
+	var fields []zap.Field
+	if tl.nestedTraceKey != "" {
+		fields = append(fields, zap.Object(tl.nestedTraceKey, spanContextMarshaler(spanCtx)))
+	} else {
+		for _, f := range []struct {
+			key, val string
+		}{
+			{key: tl.fieldNames.TraceID, val: formattedTraceID},
+			{key: tl.fieldNames.DDTraceID, val: formattedTraceID},
+			{key: tl.fieldNames.SpanID, val: spanID},
+			{key: tl.fieldNames.DDSpanID, val: spanID},
+		} {
+			if f.key != "" {
+				fields = append(fields, zap.String(f.key, f.val))
+			}
+		}
+	}
+
+	if n := tl.shortTraceID; n > 0 {
+		if n > len(traceID) {
+			n = len(traceID)
+		}
+
+		fields = append(fields, zap.String("tid", traceID[:n]))
+	}
+
+	if tl.traceURLTemplate != "" {
+		fields = append(fields, zap.String("traceURL", strings.ReplaceAll(tl.traceURLTemplate, traceURLPlaceholder, traceID)))
+	}
+
+	return fields
+}
+
+// Context returns the `context.Context` associated with the logger, defaulting to
+// `context.Background()` when none has been set.
+func (tl *TraceLogger) Context() context.Context {
+	if tl.ctx == nil {
+		return context.Background()
+	}
+
+	return tl.ctx
+}
+
+// WithCallerSkip returns a logger that skips n additional callers when annotating
+// entries with the caller. See the WithCallerSkip option for details.
+func (tl *TraceLogger) WithCallerSkip(n int) *TraceLogger {
+	l := tl.clone()
+	l.updateBase(func(lg *zap.Logger) *zap.Logger {
+		return lg.WithOptions(zap.AddCallerSkip(n))
+	})
+
+	return l
 }
 
 // With adds a variadic number of fields to the logging context. It accepts a
 // mix of strongly-typed Field objects.
 func (tl *TraceLogger) With(args ...zap.Field) *TraceLogger {
-	return &TraceLogger{base: tl.base.With(args...)}
+	if tl.fieldNamespace != "" {
+		l := tl.clone()
+		l.namespacedFields = make([]zap.Field, 0, len(tl.namespacedFields)+len(args))
+		l.namespacedFields = append(l.namespacedFields, tl.namespacedFields...)
+		l.namespacedFields = append(l.namespacedFields, args...)
+
+		return l
+	}
+
+	return tl.withCorrelation(args...)
+}
+
+// WithSpanAttributes sets the attributes on the span in the logger's `context.Context`
+// and returns a logger whose entries include them as fields, converted using
+// AttrsToFields, keeping the span and the entries in sync.
+func (tl *TraceLogger) WithSpanAttributes(attrs ...attribute.KeyValue) *TraceLogger {
+	if len(attrs) == 0 {
+		return tl
+	}
+
+	if span := trace.SpanFromContext(tl.Context()); span.IsRecording() {
+		// tagSpan sorts the attributes, so the caller's slice is copied.
+		tagSpan(tl.Context(), append([]attribute.KeyValue(nil), attrs...)...)
+	}
+
+	return tl.With(AttrsToFields(attrs...)...)
+}
+
+// withCorrelation adds fields to the base logger, outside of any field namespace.
+func (tl *TraceLogger) withCorrelation(fields ...zap.Field) *TraceLogger {
+	l := tl.clone()
+	l.updateBase(func(lg *zap.Logger) *zap.Logger {
+		return lg.With(fields...)
+	})
+
+	return l
 }
 
 // FromRequest retrieves any HTTP Headers on the provided request and associates
 // the current TraceLogger's `context.Context`.
 func (tl *TraceLogger) FromRequest(r *http.Request) *TraceLogger {
-	ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
-
-	return tl.SetContext(ctx)
+	return tl.SetContext(tl.extract(r))
 }
 
 // WithRequest tags the outgoing `http.Request` with HTTP Headers to associate any downstream
-// tracing with the provided `context.Context`.
+// tracing with the provided `context.Context`. The span in the provided context is tagged
+// with the client request attributes, and the returned request uses that context.
 func (tl *TraceLogger) WithRequest(ctx context.Context, r *http.Request) *http.Request {
-	r2 := new(http.Request)
-	*r2 = *r
+	r2 := r.Clone(ctx)
+	tl.TagClientRequest(ctx, r2)
 
-	span := trace.SpanFromContext(r2.Context())
-	if span != nil {
-		attrs := semconv.NetAttributesFromHTTPRequest("tcp", r2)
-		attrs = append(attrs, semconv.EndUserAttributesFromHTTPRequest(r2)...)
-		attrs = append(attrs, semconv.HTTPServerAttributesFromHTTPRequest("http.server", r2.URL.String(), r2)...)
+	if p := tl.textMapPropagator(); p != nil {
+		p.Inject(ctx, propagation.HeaderCarrier(r2.Header))
+	}
+
+	return r2
+}
 
-		span.SetAttributes(attrs...)
+// Propagate injects the trace context of the request's own `context.Context` into its
+// headers and tags the request's span with the client request attributes, returning
+// the enriched request. Use WithRequest to propagate a different context.
+func (tl *TraceLogger) Propagate(r *http.Request) *http.Request {
+	return tl.WithRequest(r.Context(), r)
+}
+
+// TagClientRequest tags the span in the provided `context.Context` with the attributes
+// of an outbound request. It does nothing when the span is not recording.
+func (tl *TraceLogger) TagClientRequest(ctx context.Context, r *http.Request) {
+	if span := trace.SpanFromContext(ctx); span.IsRecording() {
+		span.SetAttributes(tl.clientRequestAttributes(r)...)
 	}
+}
 
-	r2 = r2.WithContext(ctx)
+// TagServerRequest tags the span in the provided `context.Context` with the attributes
+// of an inbound request. It does nothing when the span is not recording.
+func (tl *TraceLogger) TagServerRequest(ctx context.Context, r *http.Request) {
+	if span := trace.SpanFromContext(ctx); span.IsRecording() {
+		span.SetAttributes(tl.serverRequestAttributes(r)...)
+	}
+}
 
-	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(r2.Header))
+// TagRoute tags the span in the provided `context.Context` with the matched route
+// pattern, such as `/users/{id}`. The span is only tagged when it is recording. Within
+// Middleware, the route is also added to the completion entry of the request.
+func (tl *TraceLogger) TagRoute(ctx context.Context, route string) {
+	if rt, ok := ctx.Value(requestRouteKey{}).(*requestRoute); ok {
+		rt.pattern.Store(&route)
+	}
 
-	return r2
+	if span := trace.SpanFromContext(ctx); span.IsRecording() {
+		span.SetAttributes(tl.routeAttribute(route))
+	}
+}
+
+// IsSampled reports whether the span associated with the logger's `context.Context` is
+// sampled. False is returned when there is no valid span.
+func (tl *TraceLogger) IsSampled() bool {
+	if tl.ctx == nil {
+		return false
+	}
+
+	spanCtx := trace.SpanContextFromContext(tl.ctx)
+
+	return spanCtx.IsValid() && spanCtx.IsSampled()
 }
 
 // Debug uses fmt.Sprint to construct and log a message.
 func (tl *TraceLogger) Debug(msg string, args ...interface{}) {
-	fields, tags := parseArguments(args...)
-	tagSpan(tl.ctx, tags...)
-	tl.base.Debug(msg, fields...)
+	tl.log(tl.ctx, zapcore.DebugLevel, msg, args)
 }
 
 // Info uses fmt.Sprint to construct and log a message.
 func (tl *TraceLogger) Info(msg string, args ...interface{}) {
-	fields, tags := parseArguments(args...)
-	tagSpan(tl.ctx, tags...)
-	tl.base.Info(msg, fields...)
+	tl.log(tl.ctx, zapcore.InfoLevel, msg, args)
 }
 
 // Warn uses fmt.Sprint to construct and log a message.
 func (tl *TraceLogger) Warn(msg string, args ...interface{}) {
-	fields, tags := parseArguments(args...)
-	tagSpan(tl.ctx, tags...)
-	tl.base.Warn(msg, fields...)
+	tl.log(tl.ctx, zapcore.WarnLevel, msg, args)
 }
 
 // Error uses fmt.Sprint to construct and log a message.
 func (tl *TraceLogger) Error(msg string, args ...interface{}) {
-	fields, tags := parseArguments(args...)
-	tagSpan(tl.ctx, tags...)
-	tl.base.Error(msg, fields...)
+	tl.log(tl.ctx, zapcore.ErrorLevel, msg, args)
 }
 
 // DPanic uses fmt.Sprint to construct and log a message. In development, the
 // logger then panics. (See DPanicLevel for details.)
 func (tl *TraceLogger) DPanic(msg string, args ...interface{}) {
-	fields, tags := parseArguments(args...)
-	tagSpan(tl.ctx, tags...)
-	tl.base.DPanic(msg, fields...)
+	tl.log(tl.ctx, zapcore.DPanicLevel, msg, args)
 }
 
 // Panic uses fmt.Sprint to construct and log a message, then panics.
 func (tl *TraceLogger) Panic(msg string, args ...interface{}) {
-	fields, tags := parseArguments(args...)
-	tagSpan(tl.ctx, tags...)
-	tl.base.Panic(msg, fields...)
+	tl.log(tl.ctx, zapcore.PanicLevel, msg, args)
 }
 
 // Fatal uses fmt.Sprint to construct and log a message, then calls os.Exit.
 func (tl *TraceLogger) Fatal(msg string, args ...interface{}) {
-	fields, tags := parseArguments(args...)
-	tagSpan(tl.ctx, tags...)
-	tl.base.Fatal(msg, fields...)
+	tl.log(tl.ctx, zapcore.FatalLevel, msg, args)
+}
+
+// AtLevel returns a logger which only writes entries at or above min, such as to quiet a
+// chatty component. Contexts marked using ForceVerbose still bypass the level. An error
+// is returned when min is below the level of the logger, as levels can only be increased.
+func (tl *TraceLogger) AtLevel(min zapcore.Level) (*TraceLogger, error) {
+	core, err := zapcore.NewIncreaseLevelCore(tl.base.Core(), min)
+	if err != nil {
+		return nil, fmt.Errorf("failed to increase logger level: %w", err)
+	}
+
+	l := tl.clone()
+	l.base = tl.base.WithOptions(zap.WrapCore(func(zapcore.Core) zapcore.Core {
+		return core
+	}))
+
+	if tl.unbound != nil {
+		// The unbound logger shares the level of the base logger, so it can be increased.
+		l.unbound = tl.unbound.WithOptions(zap.WrapCore(func(c zapcore.Core) zapcore.Core {
+			increased, _ := zapcore.NewIncreaseLevelCore(c, min)
+			return increased
+		}))
+	}
+
+	return l, nil
+}
+
+// Enabled reports whether entries at the provided level are written. Use it to avoid
+// constructing expensive arguments for entries which would be discarded.
+func (tl *TraceLogger) Enabled(lvl zapcore.Level) bool {
+	if tl.verbose != nil && isForcedVerbose(tl.ctx) {
+		return true
+	}
+
+	return tl.base.Core().Enabled(lvl)
+}
+
+// IsDebug reports whether DebugLevel entries are written.
+func (tl *TraceLogger) IsDebug() bool {
+	return tl.Enabled(zapcore.DebugLevel)
+}
+
+// IsInfo reports whether InfoLevel entries are written.
+func (tl *TraceLogger) IsInfo() bool {
+	return tl.Enabled(zapcore.InfoLevel)
+}
+
+// IsWarn reports whether WarnLevel entries are written.
+func (tl *TraceLogger) IsWarn() bool {
+	return tl.Enabled(zapcore.WarnLevel)
+}
+
+// IsError reports whether ErrorLevel entries are written.
+func (tl *TraceLogger) IsError() bool {
+	return tl.Enabled(zapcore.ErrorLevel)
 }
 
 // Sync flushes any buffered log entries.
@@ -166,29 +798,424 @@ func (tl *TraceLogger) Sync() error {
 	return nil
 }
 
-func parseArguments(args ...interface{}) ([]zap.Field, []attribute.KeyValue) {
+// Close syncs the logger, then closes the outputs it created, such as the background
+// writer of WithAsyncOutput or the file of WithCompressedFileOutput, stopping their
+// goroutines. Loggers derived from tl share these outputs, so none may be used once
+// it is closed.
+func (tl *TraceLogger) Close() error {
+	err := tl.Sync()
+
+	for i := len(tl.closers) - 1; i >= 0; i-- {
+		if cerr := tl.closers[i].Close(); cerr != nil {
+			err = multierr.Append(err, fmt.Errorf("failed to close output: %w", cerr))
+		}
+	}
+
+	return err
+}
+
+// log writes the entry at the provided level, or the level found in args, and tags
+// the span with any attributes found in args. The entry is checked before the fields
+// are built, so fields, context fields and formatted errors are only evaluated when
+// the entry is written.
+func (tl *TraceLogger) log(ctx context.Context, lvl zapcore.Level, msg string, args []interface{}) {
+	var (
+		tags []attribute.KeyValue
+		errs []error
+	)
+
+	// Plain messages are the most common entries, so avoid parsing when there are no
+	// arguments to keep them free of allocations.
+	if len(args) > 0 {
+		tags, errs, lvl = parseArguments(lvl, args...)
+	}
+
+	verbose := isForcedVerbose(ctx)
+
+	base := tl.base
+	if verbose && tl.verbose != nil {
+		base = tl.verbose
+	}
+
+	tl.setSpanStatus(ctx, lvl, msg)
+
+	ce := base.Check(lvl, msg)
+	tag := (verbose || lvl >= tl.minTagLevel) && trace.SpanFromContext(ctx).IsRecording()
+	if ce == nil && !tag {
+		return
+	}
+
+	var extra []zap.Field
+	if tl.contextFields != nil && ctx != nil && (ce != nil || tl.tagContextFields) {
+		extra = tl.contextFields(ctx)
+	}
+
+	if tag {
+		if tl.tagContextFields {
+			tags = append(tags, FieldsToAttrs(extra...)...)
+		}
+
+		if len(tags) > 0 || len(errs) > 0 || tl.name != "" {
+			span := trace.SpanFromContext(ctx)
+			for _, err := range errs {
+				span.RecordError(err, trace.WithAttributes(FieldsToAttrs(tl.errorFields(err)...)...))
+			}
+
+			if tl.validateAttrs {
+				tags = tl.validAttributes(ctx, tags)
+			}
+
+			if tl.name != "" {
+				tags = append(tags, attribute.String("logger.name", tl.name))
+			}
+
+			tagSpan(ctx, tags...)
+		}
+	}
+
+	if ce == nil {
+		return
+	}
+
+	tl.setExpiredStatus(ctx, args)
+
+	if tl.countSpanLogs {
+		if is, ok := trace.SpanFromContext(ctx).(*InstrumentedSpan); ok {
+			is.logCount.Add(1)
+		}
+	}
+
+	buf := fieldSlices.Get().(*[]zap.Field)
+	fields := (*buf)[:0]
+
+	if tl.fieldNamespace != "" {
+		fields = append(fields, zap.Namespace(tl.fieldNamespace))
+		fields = append(fields, tl.namespacedFields...)
+	}
+
+	fields = tl.appendArgumentFields(fields, args)
+	fields = append(fields, extra...)
+
+	// The namespace is only opened when it contains fields.
+	if tl.fieldNamespace != "" && len(fields) == 1 {
+		fields = fields[:0]
+	}
+
+	if tl.contextCores && ctx != nil {
+		fields = append(fields, contextField(ctx))
+	}
+
+	ce.Write(fields...)
+
+	if cap(fields) <= maxPooledFields {
+		clear(fields)
+		*buf = fields[:0]
+		fieldSlices.Put(buf)
+	}
+}
+
+// maxPooledFields bounds the capacity of the field slices returned to fieldSlices, so
+// an entry with many fields does not keep a large slice alive.
+const maxPooledFields = 64
+
+// fieldSlices pools the slices of fields written by log. Cores must not retain the
+// fields of an entry once written, so the slices are reused by later entries.
+var fieldSlices = sync.Pool{
+	New: func() interface{} {
+		fields := make([]zap.Field, 0, 8)
+
+		return &fields
+	},
+}
+
+// setSpanStatus sets the status of the span in ctx for an entry at lvl, when enabled
+// using WithSpanStatusMinLevel or WithStatusMapper. The level is checked before the
+// mapper is called.
+func (tl *TraceLogger) setSpanStatus(ctx context.Context, lvl zapcore.Level, msg string) {
+	if tl.spanStatus {
+		if lvl < tl.spanStatusLevel {
+			return
+		}
+	} else if tl.statusMapper == nil {
+		return
+	}
+
+	span := trace.SpanFromContext(ctx)
+	if !span.IsRecording() {
+		return
+	}
+
+	code, description := codes.Error, msg
+	if tl.statusMapper != nil {
+		code, description = tl.statusMapper(lvl, msg)
+		if code == codes.Unset {
+			return
+		}
+	}
+
+	span.SetStatus(code, description)
+}
+
+// newBase builds the base logger from the configured encoder, output and level,
+// defaulting to zap's production JSON encoding on stdout at InfoLevel. The verbose
+// logger shares the output of the base logger, but writes entries at every level.
+func (tl *TraceLogger) newBase() (base, verbose *zap.Logger) {
+	unfiltered := *tl
+	unfiltered.level = zapcore.DebugLevel
+
+	// Level split outputs are written by a core per level rather than by decoding the
+	// level of each encoded entry.
+	if split, ok := tl.output.(*levelSplitWriteSyncer); ok {
+		split = split.mapSyncers(tl.wrapOutput)
+
+		return zap.New(split.newCore(tl.newOutputCore), zap.AddCaller()),
+			zap.New(split.newCore(unfiltered.newOutputCore), zap.AddCaller())
+	}
+
+	out := tl.output
+	if out == nil {
+		out = zapcore.Lock(os.Stdout)
+	}
+
+	out = tl.wrapOutput(out)
+
+	return zap.New(tl.newOutputCore(out), zap.AddCaller()),
+		zap.New(unfiltered.newOutputCore(out), zap.AddCaller())
+}
+
+// wrapOutput applies the configured encryption and buffering to out.
+func (tl *TraceLogger) wrapOutput(out zapcore.WriteSyncer) zapcore.WriteSyncer {
+	if tl.encryptionKey != nil {
+		// The key is validated when the option is applied.
+		out, _ = NewEncryptedWriteSyncer(out, tl.encryptionKey)
+	}
+
+	if tl.asyncBufferSize > 0 {
+		out = NewAsyncWriteSyncer(out, tl.asyncBufferSize)
+		tl.addCloser(out)
+	}
+
+	return out
+}
+
+// addCloser records ws to be closed by Close when it implements `io.Closer`.
+func (tl *TraceLogger) addCloser(ws zapcore.WriteSyncer) {
+	if c, ok := ws.(io.Closer); ok {
+		tl.closers = append(tl.closers, c)
+	}
+}
+
+// newOutputCore creates a core writing to out using the configured encoder and level.
+func (tl *TraceLogger) newOutputCore(out zapcore.WriteSyncer) zapcore.Core {
+	return tl.newLevelCore(tl.newEncoder(), out)
+}
+
+// updateBase replaces the base loggers with the result of f.
+func (tl *TraceLogger) updateBase(f func(*zap.Logger) *zap.Logger) {
+	tl.base = f(tl.base)
+	if tl.verbose != nil {
+		tl.verbose = f(tl.verbose)
+	}
+
+	if tl.unbound != nil {
+		tl.unbound = f(tl.unbound)
+	}
+
+	if tl.unboundVerbose != nil {
+		tl.unboundVerbose = f(tl.unboundVerbose)
+	}
+}
+
+// teeCore writes entries to the tee outputs in addition to the provided core.
+func (tl *TraceLogger) teeCore(core zapcore.Core) zapcore.Core {
+	cores := []zapcore.Core{core}
+	for _, ws := range tl.teeOutputs {
+		cores = append(cores, tl.newLevelCore(tl.newEncoder(), ws))
+	}
+
+	return zapcore.NewTee(cores...)
+}
+
+// newEncoder returns a copy of the configured encoder, defaulting to JSON or logfmt
+// encoding using the configured encoder config.
+func (tl *TraceLogger) newEncoder() zapcore.Encoder {
+	if tl.encoder != nil {
+		return tl.encoder.Clone()
+	}
+
+	cfg := zap.NewProductionEncoderConfig()
+	if tl.encoderConfig != nil {
+		cfg = *tl.encoderConfig
+	}
+
+	if tl.timeEncoder != nil {
+		cfg.EncodeTime = tl.timeEncoder
+	}
+
+	if tl.logfmt {
+		return NewLogfmtEncoder(cfg)
+	}
+
+	return zapcore.NewJSONEncoder(cfg)
+}
+
+// levelEnabler returns the configured level, defaulting to InfoLevel.
+func (tl *TraceLogger) levelEnabler() zapcore.LevelEnabler {
+	if tl.level == nil {
+		return zapcore.InfoLevel
+	}
+
+	return tl.level
+}
+
+// reportError writes errors encountered while applying options to stderr, as the
+// base logger may not exist yet.
+func (tl *TraceLogger) reportError(err error) {
+	fmt.Fprintf(os.Stderr, "tracelog: %v\n", err)
+}
+
+// extract returns the request's `context.Context` with any trace context propagated
+// in the request headers.
+func (tl *TraceLogger) extract(r *http.Request) context.Context {
+	ctx := r.Context()
+	if p := tl.textMapPropagator(); p != nil {
+		ctx = p.Extract(ctx, propagation.HeaderCarrier(r.Header))
+	}
+
+	if tl.queryParamKey != "" && !trace.SpanContextFromContext(ctx).IsValid() {
+		if v := r.URL.Query().Get(tl.queryParamKey); v != "" {
+			ctx = propagation.TraceContext{}.Extract(ctx, propagation.HeaderCarrier(http.Header{http.CanonicalHeaderKey(traceparentHeader): {v}}))
+		}
+	}
+
+	return ctx
+}
+
+// textMapPropagator returns the configured propagator, falling back to the global
+// propagator, composed with baggage propagation when configured. Nil is returned when
+// the result does not propagate any fields.
+func (tl *TraceLogger) textMapPropagator() propagation.TextMapPropagator {
+	p := tl.propagator
+	if p == nil {
+		p = otel.GetTextMapPropagator()
+	}
+
+	if tl.baggage && !propagatesBaggage(p) {
+		if p == nil {
+			p = propagation.Baggage{}
+		} else {
+			p = propagation.NewCompositeTextMapPropagator(p, propagation.Baggage{})
+		}
+	}
+
+	if p == nil || len(p.Fields()) == 0 {
+		return nil
+	}
+
+	return p
+}
+
+// propagatesBaggage reports whether p propagates the baggage header.
+func propagatesBaggage(p propagation.TextMapPropagator) bool {
+	if p == nil {
+		return false
+	}
+
+	for _, f := range p.Fields() {
+		if f == baggageHeader {
+			return true
+		}
+	}
+
+	return false
+}
+
+// spanContextMarshaler encodes a span context as a nested object.
+type spanContextMarshaler trace.SpanContext
+
+func (m spanContextMarshaler) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	spanCtx := trace.SpanContext(m)
+
+	enc.AddString("trace_id", spanCtx.TraceID().String())
+	enc.AddString("span_id", spanCtx.SpanID().String())
+	enc.AddString("trace_flags", spanCtx.TraceFlags().String())
+
+	if state := spanCtx.TraceState().String(); state != "" {
+		enc.AddString("trace_state", state)
+	}
+
+	return nil
+}
+
+// clone returns a shallow copy of the TraceLogger so derived loggers retain the
+// configured options.
+func (tl *TraceLogger) clone() *TraceLogger {
+	l := *tl
+
+	return &l
+}
+
+// parseArguments sorts the arguments of a log call into span attributes and errors.
+// The level found in args, if any, is returned in place of lvl.
+func parseArguments(lvl zapcore.Level, args ...interface{}) ([]attribute.KeyValue, []error, zapcore.Level) {
 	var (
-		tags   []attribute.KeyValue
-		fields []zap.Field
+		tags []attribute.KeyValue
+		errs []error
 	)
 
 	for _, arg := range args {
 		switch v := arg.(type) {
 		case attribute.KeyValue:
 			tags = append(tags, v)
+		case zapcore.Level:
+			lvl = v
+		case error:
+			errs = append(errs, v)
+		}
+	}
+
+	return tags, errs, lvl
+}
+
+// appendArgumentFields appends the fields found in the arguments of a log call to
+// fields, in order, with errors formatted as fields.
+func (tl *TraceLogger) appendArgumentFields(fields []zap.Field, args []interface{}) []zap.Field {
+	for _, arg := range args {
+		switch v := arg.(type) {
 		case zap.Field:
 			fields = append(fields, v)
+		case contextExpired:
+			fields = append(fields, zap.Bool("context_expired", true))
+		case error:
+			fields = append(fields, tl.errorFields(v)...)
 		}
 	}
 
-	return fields, tags
+	return fields
+}
+
+// errorFields formats err using the configured error formatter, defaulting to zap.Error
+// along with the span context of errors wrapped using WrapError.
+func (tl *TraceLogger) errorFields(err error) []zap.Field {
+	if tl.errorFormatter != nil {
+		return tl.errorFormatter(err)
+	}
+
+	return append([]zap.Field{zap.Error(err)}, errorSpanFields(err)...)
 }
 
+// tagSpan sets the attributes on the span in ctx, sorted by key so the order does not
+// depend on how the attributes were gathered. Later attributes take precedence when
+// keys are repeated.
 func tagSpan(ctx context.Context, tags ...attribute.KeyValue) {
 	span := trace.SpanFromContext(ctx)
 	if span == nil {
 		return
 	}
 
+	sort.SliceStable(tags, func(i, j int) bool {
+		return tags[i].Key < tags[j].Key
+	})
+
 	span.SetAttributes(tags...)
 }
@@ -0,0 +1,485 @@
+package tracelog
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// newDiscardLogger creates a logger encoding JSON entries at InfoLevel and discarding them.
+func newDiscardLogger(opts ...LoggerOption) *TraceLogger {
+	core := zapcore.NewCore(
+		zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()),
+		zapcore.AddSync(io.Discard),
+		zapcore.InfoLevel,
+	)
+
+	return NewLogger(append([]LoggerOption{WithLogger(zap.New(core))}, opts...)...)
+}
+
+func TestPlainMessageAllocations(t *testing.T) {
+	if raceEnabled {
+		t.Skip("the race detector adds allocations")
+	}
+
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSampler(sdktrace.NeverSample()))
+	unsampled, span := tp.Tracer("test").Start(context.Background(), "unsampled")
+	defer span.End()
+
+	tl := newDiscardLogger()
+	bound := tl.SetContext(contextWithSpan(1, 1))
+	notRecording := tl.SetContext(unsampled)
+
+	tests := []struct {
+		name string
+		log  func()
+	}{
+		{
+			name: "without context",
+			log:  func() { tl.Info("msg") },
+		},
+		{
+			name: "bound context",
+			log:  func() { bound.Info("msg") },
+		},
+		{
+			name: "unsampled span",
+			log:  func() { notRecording.Info("msg") },
+		},
+		{
+			name: "disabled level",
+			log:  func() { bound.Debug("msg") },
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			if allocs := testing.AllocsPerRun(100, tt.log); allocs != 0 {
+				t.Errorf("got %v allocations, want 0", allocs)
+			}
+		})
+	}
+}
+
+func TestConcurrentLogger(t *testing.T) {
+	tl, buf := newBufferedLogger(WithLevelEnabler(zapcore.DebugLevel))
+	shared := tl.SetContext(contextWithSpan(1, 1)).With(zap.String("shared", "value"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			name := strconv.Itoa(i)
+			for ctx.Err() == nil {
+				r := httptest.NewRequest(http.MethodGet, "/", nil)
+				r = shared.WithRequest(contextWithSpan(2, byte(i)), r)
+
+				lg := shared.FromRequest(r).
+					SetContext(contextWithSpan(3, byte(i))).
+					With(zap.String("goroutine", name)).
+					Named(name)
+
+				lg.Debug("debug", attribute.String("goroutine", name))
+				lg.Info("info", zap.Int("i", i))
+				lg.Warn("warn")
+				lg.Error("error", errors.New("failure"))
+				_ = lg.Rebind(ctx).Named("rebound").Context()
+
+				sl, span := lg.StartSpan("work")
+				sl.Event("event")
+				span.End()
+				_ = shared.Enabled(zapcore.InfoLevel)
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	// Copies derived concurrently must not share their fields or names.
+	for _, entry := range buf.Entries(t) {
+		if name, ok := entry["logger"].(string); !ok || name != entry["goroutine"] {
+			t.Fatalf("entry of logger %v has goroutine field %v", entry["logger"], entry["goroutine"])
+		}
+
+		if entry["shared"] != "value" {
+			t.Fatalf("entry is missing the shared field: %v", entry)
+		}
+	}
+}
+
+func TestNilPropagator(t *testing.T) {
+	prev := otel.GetTextMapPropagator()
+	t.Cleanup(func() {
+		otel.SetTextMapPropagator(prev)
+	})
+
+	tl, _ := newBufferedLogger(WithPropagator(nil))
+	ctx := contextWithSpan(1, 2)
+
+	t.Run("no-op global propagator", func(t *testing.T) {
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator())
+
+		r := tl.WithRequest(ctx, httptest.NewRequest(http.MethodGet, "/", nil))
+		if h := r.Header.Get(traceparentHeader); h != "" {
+			t.Errorf("injected %s header %q using a no-op propagator", traceparentHeader, h)
+		}
+
+		if sc := trace.SpanContextFromContext(tl.FromRequest(r.WithContext(context.Background())).Context()); sc.IsValid() {
+			t.Errorf("extracted span context %v using a no-op propagator", sc)
+		}
+	})
+
+	t.Run("global propagator", func(t *testing.T) {
+		otel.SetTextMapPropagator(propagation.TraceContext{})
+
+		r := tl.WithRequest(ctx, httptest.NewRequest(http.MethodGet, "/", nil))
+		got := trace.SpanContextFromContext(tl.FromRequest(r.WithContext(context.Background())).Context())
+
+		if want := trace.SpanContextFromContext(ctx); got.TraceID() != want.TraceID() || got.SpanID() != want.SpanID() {
+			t.Errorf("extracted span context %v, want %v", got, want)
+		}
+	})
+}
+
+func BenchmarkSetContext(b *testing.B) {
+	tl := newDiscardLogger()
+
+	b.Run("valid span context", func(b *testing.B) {
+		ctx := contextWithSpan(1, 2)
+
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			tl.SetContext(ctx)
+		}
+	})
+
+	b.Run("invalid span context", func(b *testing.B) {
+		ctx := context.Background()
+
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			tl.SetContext(ctx)
+		}
+	})
+}
+
+// recordingFatalHook records the levels seen by the entry hooks when the process would exit.
+type recordingFatalHook struct {
+	seen *[]zapcore.Level
+	at   []zapcore.Level
+}
+
+func (h *recordingFatalHook) OnWrite(*zapcore.CheckedEntry, []zapcore.Field) {
+	h.at = append([]zapcore.Level(nil), *h.seen...)
+}
+
+func TestWithHooksFiresForEveryLevel(t *testing.T) {
+	var seen []zapcore.Level
+	fatal := &recordingFatalHook{seen: &seen}
+
+	tl, _ := newBufferedLogger(
+		WithLevelEnabler(zapcore.DebugLevel),
+		WithHooks(func(ent zapcore.Entry) error {
+			seen = append(seen, ent.Level)
+			return nil
+		}),
+		WithFatalHook(fatal),
+	)
+
+	tl.Debug("debug")
+	tl.Info("info")
+	tl.Warn("warn")
+	tl.Error("error")
+	tl.DPanic("dpanic")
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Panic did not panic")
+			}
+		}()
+
+		tl.Panic("panic")
+	}()
+	tl.Fatal("fatal")
+
+	want := []zapcore.Level{
+		zapcore.DebugLevel,
+		zapcore.InfoLevel,
+		zapcore.WarnLevel,
+		zapcore.ErrorLevel,
+		zapcore.DPanicLevel,
+		zapcore.PanicLevel,
+		zapcore.FatalLevel,
+	}
+
+	if !reflect.DeepEqual(seen, want) {
+		t.Errorf("hooks fired for %v, want %v", seen, want)
+	}
+
+	if !reflect.DeepEqual(fatal.at, want) {
+		t.Errorf("hooks fired for %v before the fatal hook, want %v", fatal.at, want)
+	}
+}
+
+func TestWithoutCaller(t *testing.T) {
+	tl, buf := newBufferedLogger()
+	tl.Info("with caller")
+
+	if _, ok := buf.Entries(t)[0]["caller"]; !ok {
+		t.Fatalf("entry %s does not contain the caller key", buf.String())
+	}
+
+	tl, buf = newBufferedLogger(WithoutCaller())
+	tl.SetContext(contextWithSpan(1, 2)).Info("without caller")
+
+	if strings.Contains(buf.String(), `"caller"`) {
+		t.Errorf("entry %s contains the caller key", buf.String())
+	}
+}
+
+func TestWithRequestUsesProvidedContext(t *testing.T) {
+	rec := useSpanRecorder(t)
+	tl, _ := newBufferedLogger(WithPropagator(propagation.TraceContext{}))
+
+	_, reqSpan := tl.SetContext(context.Background()).StartSpan("request context")
+	ctxLogger, ctxSpan := tl.SetContext(context.Background()).StartSpan("provided context")
+
+	r := httptest.NewRequest(http.MethodGet, "http://example.com/", nil)
+	r = r.WithContext(trace.ContextWithSpan(r.Context(), reqSpan))
+
+	r2 := tl.WithRequest(ctxLogger.Context(), r)
+	reqSpan.End()
+	ctxSpan.End()
+
+	if got := trace.SpanContextFromContext(r2.Context()); got.SpanID() != ctxSpan.SpanContext().SpanID() {
+		t.Errorf("request context span = %s, want %s", got.SpanID(), ctxSpan.SpanContext().SpanID())
+	}
+
+	got := trace.SpanContextFromContext(propagation.TraceContext{}.Extract(context.Background(), propagation.HeaderCarrier(r2.Header)))
+	if got.SpanID() != ctxSpan.SpanContext().SpanID() {
+		t.Errorf("injected span = %s, want %s", got.SpanID(), ctxSpan.SpanContext().SpanID())
+	}
+
+	for _, span := range rec.Ended() {
+		var tagged bool
+		for _, attr := range span.Attributes() {
+			if attr.Key == semconv.HTTPMethodKey {
+				tagged = true
+			}
+		}
+
+		if want := span.Name() == "provided context"; tagged != want {
+			t.Errorf("span %q tagged with the request attributes: %t, want %t", span.Name(), tagged, want)
+		}
+	}
+}
+
+func TestTagSpanSortsAttributes(t *testing.T) {
+	rec := useSpanRecorder(t)
+	tl, _ := newBufferedLogger()
+
+	lg, span := tl.SetContext(context.Background()).StartSpan("tagged")
+	lg.Info(
+		"msg",
+		attribute.String("zeta", "z"),
+		attribute.String("alpha", "a"),
+		attribute.Int("mid", 1),
+		attribute.String("alpha", "b"),
+	)
+	span.End()
+
+	var keys []string
+	for _, attr := range rec.Ended()[0].Attributes() {
+		keys = append(keys, string(attr.Key))
+	}
+
+	if want := []string{"alpha", "mid", "zeta"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("attribute keys = %v, want %v", keys, want)
+	}
+
+	for _, attr := range rec.Ended()[0].Attributes() {
+		if attr.Key == "alpha" && attr.Value.AsString() != "b" {
+			t.Errorf("alpha = %s, want the later value b", attr.Value.AsString())
+		}
+	}
+}
+
+func BenchmarkRebind(b *testing.B) {
+	ctxs := make([]context.Context, 16)
+	for i := range ctxs {
+		ctxs[i] = contextWithSpan(1, byte(i+1))
+	}
+
+	lg := newDiscardLogger().SetContext(ctxs[0]).With(zap.String("job", "batch"))
+
+	b.Run("SetContext", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			lg.SetContext(ctxs[i%len(ctxs)]).Info("iteration")
+		}
+	})
+
+	b.Run("Rebind", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			lg.Rebind(ctxs[i%len(ctxs)]).Info("iteration")
+		}
+	})
+}
+
+func TestCorrelationFieldsAbsentWithoutSpan(t *testing.T) {
+	tests := []struct {
+		name string
+		log  func(tl *TraceLogger)
+	}{
+		{
+			name: "without context",
+			log:  func(tl *TraceLogger) { tl.Info("msg") },
+		},
+		{
+			name: "context without span",
+			log:  func(tl *TraceLogger) { tl.SetContext(context.Background()).Info("msg") },
+		},
+		{
+			name: "request without trace headers",
+			log: func(tl *TraceLogger) {
+				tl.FromRequest(httptest.NewRequest(http.MethodGet, "/", nil)).Info("msg")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			tl, buf := newBufferedLogger()
+			tt.log(tl)
+
+			entry := buf.Entries(t)[0]
+			for _, key := range correlationKeys {
+				if v, ok := entry[key]; ok {
+					t.Errorf("entry contains %s=%v", key, v)
+				}
+			}
+		})
+	}
+}
+
+func TestBaggagePropagationRoundTrip(t *testing.T) {
+	member, err := baggage.NewMember("tenant", "acme")
+	if err != nil {
+		t.Fatalf("failed to create baggage member: %v", err)
+	}
+
+	bag, err := baggage.New(member)
+	if err != nil {
+		t.Fatalf("failed to create baggage: %v", err)
+	}
+
+	ctx := baggage.ContextWithBaggage(contextWithSpan(1, 2), bag)
+
+	for _, tt := range []struct {
+		name string
+		opts []LoggerOption
+		want string
+	}{
+		{
+			name: "without baggage propagation",
+			opts: []LoggerOption{WithPropagator(propagation.TraceContext{})},
+		},
+		{
+			name: "with baggage propagation",
+			opts: []LoggerOption{WithPropagator(propagation.TraceContext{}), WithBaggagePropagation()},
+			want: "acme",
+		},
+	} {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			client, _ := newBufferedLogger(tt.opts...)
+			server, _ := newBufferedLogger(tt.opts...)
+
+			r := client.WithRequest(ctx, httptest.NewRequest(http.MethodGet, "/", nil))
+			got := server.FromRequest(r.WithContext(context.Background())).Context()
+
+			if v := baggage.FromContext(got).Member("tenant").Value(); v != tt.want {
+				t.Errorf("extracted tenant baggage %q, want %q", v, tt.want)
+			}
+
+			if sc := trace.SpanContextFromContext(got); sc.TraceID() != spanContext(1, 2).TraceID() {
+				t.Errorf("extracted trace ID %s, want %s", sc.TraceID(), spanContext(1, 2).TraceID())
+			}
+		})
+	}
+}
+
+func TestStatusMapperAppliedAfterMinLevel(t *testing.T) {
+	rec := useSpanRecorder(t)
+
+	var mapped []zapcore.Level
+	tl, _ := newBufferedLogger(
+		WithSpanStatusMinLevel(zapcore.ErrorLevel),
+		WithStatusMapper(func(lvl zapcore.Level, msg string) (codes.Code, string) {
+			mapped = append(mapped, lvl)
+
+			return codes.Error, "mapped: " + msg
+		}),
+	)
+
+	lg, span := tl.SetContext(context.Background()).StartSpan("mapped")
+	lg.Warn("slow")
+	lg.Error("failed")
+	span.End()
+
+	if want := []zapcore.Level{zapcore.ErrorLevel}; !reflect.DeepEqual(mapped, want) {
+		t.Errorf("mapped levels = %v, want %v", mapped, want)
+	}
+
+	if got := rec.Ended()[0].Status(); got.Code != codes.Error || got.Description != "mapped: failed" {
+		t.Errorf("status = %+v, want the mapped error", got)
+	}
+}
+
+func TestExpiredContextRespectsSpanStatusMinLevel(t *testing.T) {
+	rec := useSpanRecorder(t)
+	tl, _ := newBufferedLogger(WithSpanStatusMinLevel(zapcore.ErrorLevel))
+
+	lg, span := tl.SetContext(context.Background()).StartSpan("expired")
+
+	ctx, cancel := context.WithCancel(lg.Context())
+	cancel()
+
+	lg.InfoCtx(ctx, "gave up")
+	span.End()
+
+	if got := rec.Ended()[0].Status(); got.Code != codes.Unset {
+		t.Errorf("status = %+v, want unset below the minimum level", got)
+	}
+}
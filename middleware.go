@@ -0,0 +1,228 @@
+package tracelog
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+type middlewareConfig struct {
+	maxBodyBytes    int
+	requestIDHeader string
+}
+
+// MiddlewareOption configures the HTTP Middleware.
+type MiddlewareOption func(*middlewareConfig)
+
+// WithBodyCapture logs up to maxBytes of the request and response bodies, truncating
+// larger bodies. The request body remains readable by the handler.
+//
+// Bodies frequently contain credentials and personal data, so only enable this
+// where it is safe for those to be written to the logs.
+func WithBodyCapture(maxBytes int) MiddlewareOption {
+	return func(cfg *middlewareConfig) {
+		if cfg != nil {
+			cfg.maxBodyBytes = maxBytes
+		}
+	}
+}
+
+// Middleware starts a server span for each request, continuing any trace propagated in
+// the request headers. A logger associated with the span is added to the request's
+// `context.Context`, and can be retrieved using FromContext. Once the handler returns, the
+// response status code is recorded on the span and a completion entry is logged using
+// AccessLog, with the status and the size of the response body, along with the `route`
+// field when the route pattern is set using TagRoute. See WithRequestIDKey to return a
+// request ID to clients.
+func Middleware(tl *TraceLogger, opts ...MiddlewareOption) func(http.Handler) http.Handler {
+	cfg := &middlewareConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, span := tl.tracer().Start(
+				tl.extract(r),
+				"HTTP "+r.Method,
+				tl.startOptions(
+					trace.SpanKindServer,
+					trace.WithAttributes(tl.serverRequestAttributes(r)...),
+				)...,
+			)
+			defer span.End()
+
+			route := &requestRoute{}
+			ctx = context.WithValue(ctx, requestRouteKey{}, route)
+
+			lg := tl.SetContext(ctx)
+
+			if cfg.requestIDHeader != "" {
+				if id := requestID(r, cfg.requestIDHeader); id != "" {
+					lg = lg.With(zap.String(requestIDField, id))
+					w = RequestIDResponseWriter(w, id, cfg.requestIDHeader)
+				}
+			}
+
+			r = r.WithContext(NewContext(ctx, lg))
+
+			rw := NewResponseWriter(w)
+			w = rw
+
+			if cfg.maxBodyBytes > 0 {
+				var reqBody string
+				reqBody, r.Body = captureBody(r.Body, cfg.maxBodyBytes)
+
+				bw := &bodyCaptureWriter{ResponseWriter: rw, max: cfg.maxBodyBytes}
+				w = bw
+
+				defer func() {
+					args := []interface{}{
+						zap.String("requestBody", reqBody),
+						zap.String("responseBody", bw.body()),
+					}
+					for _, f := range rw.Fields() {
+						args = append(args, f)
+					}
+
+					lg.Info("captured HTTP bodies", args...)
+				}()
+			}
+
+			start := time.Now()
+			next.ServeHTTP(w, r)
+
+			span.SetAttributes(tl.statusCodeAttribute(rw.StatusCode()))
+			if rw.StatusCode() >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(rw.StatusCode()))
+			}
+
+			al := lg
+			if pattern := route.get(); pattern != "" {
+				al = lg.With(zap.String(routeField, pattern))
+			}
+
+			al.AccessLog(r, rw.StatusCode(), rw.BytesWritten(), time.Since(start))
+		})
+	}
+}
+
+// routeField is the key of the route pattern in the completion entry of Middleware.
+const routeField = "route"
+
+type requestRouteKey struct{}
+
+// requestRoute holds the route pattern of a request served by Middleware, set by
+// routers once the request is routed using TagRoute.
+type requestRoute struct {
+	pattern atomic.Pointer[string]
+}
+
+func (r *requestRoute) get() string {
+	if p := r.pattern.Load(); p != nil {
+		return *p
+	}
+
+	return ""
+}
+
+// RecoveryMiddleware recovers from panics raised by the wrapped `http.Handler`, logging
+// the recovered value along with the stack trace and marking the request's span as
+// errored. A 500 response is written if the handler has not already written a response.
+// The panic is not re-raised, with the exception of `http.ErrAbortHandler` which
+// the `http.Server` relies on to abort the response.
+//
+// Mount it inside Middleware so the panic is logged by the request's logger. Outside
+// Middleware, a server span is started for requests whose context has no span, so the
+// panic is still correlated with a trace and recorded on a span.
+func RecoveryMiddleware(tl *TraceLogger) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rw := &recoveryWriter{ResponseWriter: w}
+
+			if FromContext(r.Context()) == nil && !trace.SpanContextFromContext(r.Context()).IsValid() {
+				ctx, span := tl.tracer().Start(
+					tl.extract(r),
+					"HTTP "+r.Method,
+					tl.startOptions(
+						trace.SpanKindServer,
+						trace.WithAttributes(tl.serverRequestAttributes(r)...),
+					)...,
+				)
+				defer span.End()
+
+				r = r.WithContext(ctx)
+			}
+
+			defer func() {
+				rec := recover()
+				if rec == nil {
+					return
+				}
+
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+
+				lg := tl
+				if l := FromContext(r.Context()); l != nil {
+					lg = l
+				}
+
+				lg.logPanic(r.Context(), "panic in HTTP handler", rec)
+
+				if !rw.wroteHeader {
+					http.Error(rw, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				}
+			}()
+
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// recoveryWriter tracks whether a response has been started so recovery only
+// writes a status when it is still possible to do so. Flush and Hijack are delegated
+// to the wrapped writer when it supports them.
+type recoveryWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+func (w *recoveryWriter) WriteHeader(code int) {
+	w.wroteHeader = true
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *recoveryWriter) Write(b []byte) (int, error) {
+	w.wroteHeader = true
+
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *recoveryWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		w.wroteHeader = true
+		f.Flush()
+	}
+}
+
+func (w *recoveryWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+
+	// The connection belongs to the handler once hijacked, so no status can be written.
+	w.wroteHeader = true
+
+	return h.Hijack()
+}
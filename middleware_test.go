@@ -0,0 +1,106 @@
+package tracelog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+)
+
+func TestMiddlewareLogsCompletionEntry(t *testing.T) {
+	useSpanRecorder(t)
+	tl, buf := newBufferedLogger()
+
+	h := Middleware(tl)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("hello"))
+	}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/items", nil))
+
+	entries := buf.Entries(t)
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want the completion entry", len(entries))
+	}
+
+	entry := entries[0]
+	if entry["msg"] != "HTTP request" || entry["path"] != "/items" {
+		t.Errorf("entry %v is not the access log entry of the request", entry)
+	}
+
+	if entry["status"] != float64(http.StatusCreated) || entry["size"] != float64(len("hello")) {
+		t.Errorf("status = %v, size = %v, want %d and %d", entry["status"], entry["size"], http.StatusCreated, len("hello"))
+	}
+
+	if entry[DefaultFieldNames.TraceID] == nil {
+		t.Errorf("entry %v is not correlated with the request's span", entry)
+	}
+}
+
+func TestRecoveryMiddlewareCorrelatesPanicsInEitherOrder(t *testing.T) {
+	panicking := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		panic("boom")
+	})
+
+	tests := []struct {
+		name    string
+		handler func(tl *TraceLogger) http.Handler
+	}{
+		{
+			name: "inside Middleware",
+			handler: func(tl *TraceLogger) http.Handler {
+				return Middleware(tl)(RecoveryMiddleware(tl)(panicking))
+			},
+		},
+		{
+			name: "outside Middleware",
+			handler: func(tl *TraceLogger) http.Handler {
+				return RecoveryMiddleware(tl)(Middleware(tl)(panicking))
+			},
+		},
+		{
+			name: "without Middleware",
+			handler: func(tl *TraceLogger) http.Handler {
+				return RecoveryMiddleware(tl)(panicking)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			rec := useSpanRecorder(t)
+			tl, buf := newBufferedLogger()
+
+			w := httptest.NewRecorder()
+			tt.handler(tl).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			if w.Code != http.StatusInternalServerError {
+				t.Errorf("status = %d, want %d", w.Code, http.StatusInternalServerError)
+			}
+
+			var entry map[string]interface{}
+			for _, e := range buf.Entries(t) {
+				if e["msg"] == "panic in HTTP handler" {
+					entry = e
+				}
+			}
+
+			if entry == nil {
+				t.Fatalf("panic was not logged in %s", buf.String())
+			}
+
+			var errored bool
+			for _, span := range rec.Ended() {
+				if span.SpanContext().SpanID().String() == entry[DefaultFieldNames.SpanID] {
+					errored = span.Status().Code == codes.Error
+				}
+			}
+
+			if !errored {
+				t.Errorf("entry %v is not correlated with a span marked as errored", entry)
+			}
+		})
+	}
+}
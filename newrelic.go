@@ -0,0 +1,173 @@
+package tracelog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	newRelicLogAPI   = "https://log-api.newrelic.com/log/v1"
+	newRelicLogAPIEU = "https://log-api.eu.newrelic.com/log/v1"
+)
+
+// newRelicFieldNames maps field keys emitted by the TraceLogger to the fields New Relic
+// uses for log correlation. Keys mapped to an empty string are dropped.
+var newRelicFieldNames = map[string]string{
+	"traceID":    "trace.id",
+	"spanID":     "span.id",
+	"msg":        "message",
+	"dd.traceID": "",
+	"dd.spanID":  "",
+}
+
+type newRelicConfig struct {
+	endpoint     string
+	serviceName  string
+	batchTimeout time.Duration
+	client       *http.Client
+}
+
+// NewRelicOption configures the New Relic WriteSyncer.
+type NewRelicOption func(*newRelicConfig)
+
+// WithEU sends logs to the New Relic EU datacenter.
+func WithEU() NewRelicOption {
+	return func(cfg *newRelicConfig) {
+		if cfg != nil {
+			cfg.endpoint = newRelicLogAPIEU
+		}
+	}
+}
+
+// WithNewRelicServiceName sets the service name used as the `entity.guid` of each record.
+// Defaults to the `OTEL_SERVICE_NAME` environment variable, falling back to the
+// name of the running executable.
+func WithNewRelicServiceName(name string) NewRelicOption {
+	return func(cfg *newRelicConfig) {
+		if cfg != nil {
+			cfg.serviceName = name
+		}
+	}
+}
+
+// WithNewRelicBatchTimeout sets the maximum amount of time records are buffered
+// before being sent. Defaults to one second.
+func WithNewRelicBatchTimeout(d time.Duration) NewRelicOption {
+	return func(cfg *newRelicConfig) {
+		if cfg != nil {
+			cfg.batchTimeout = d
+		}
+	}
+}
+
+// NewNewRelicWriteSyncer creates a `zapcore.WriteSyncer` that buffers JSON encoded
+// entries and sends them to the New Relic Log API, including the `trace.id` and
+// `span.id` fields New Relic uses to correlate logs with APM traces. The returned
+// WriteSyncer implements `io.Closer`.
+func NewNewRelicWriteSyncer(licenseKey string, opts ...NewRelicOption) (zapcore.WriteSyncer, error) {
+	if licenseKey == "" {
+		return nil, errors.New("new relic license key is required")
+	}
+
+	cfg := &newRelicConfig{
+		endpoint:    newRelicLogAPI,
+		serviceName: defaultServiceName(),
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	send := func(entries [][]byte) error {
+		records := make([]map[string]interface{}, 0, len(entries))
+		for _, entry := range entries {
+			records = append(records, newRelicRecord(entry, cfg.serviceName))
+		}
+
+		body, err := json.Marshal(records)
+		if err != nil {
+			return fmt.Errorf("failed to encode New Relic records: %w", err)
+		}
+
+		req, err := http.NewRequest(http.MethodPost, cfg.endpoint, bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("failed to create New Relic request: %w", err)
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-License-Key", licenseKey)
+
+		return sendRequest(cfg.client, req)
+	}
+
+	return newBatchSyncer(defaultBatchSize, cfg.batchTimeout, send), nil
+}
+
+// WithNewRelicOutput sends entries to the New Relic Log API instead of stdout.
+func WithNewRelicOutput(licenseKey string, opts ...NewRelicOption) LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl == nil {
+			return
+		}
+
+		ws, err := NewNewRelicWriteSyncer(licenseKey, opts...)
+		if err != nil {
+			tl.reportError(fmt.Errorf("failed to create New Relic output: %w", err))
+
+			return
+		}
+
+		tl.output = ws
+		tl.addCloser(ws)
+	}
+}
+
+// newRelicRecord decodes a JSON encoded entry into a New Relic log record, falling
+// back to sending the raw entry as the message when it isn't JSON.
+func newRelicRecord(entry []byte, serviceName string) map[string]interface{} {
+	now := time.Now()
+	data := map[string]interface{}{}
+
+	if err := json.Unmarshal(entry, &data); err != nil {
+		data = map[string]interface{}{
+			"message": string(bytes.TrimSpace(entry)),
+		}
+	}
+
+	// Timestamps which cannot be parsed are kept as a field rather than lost.
+	if v, ok := data["ts"]; ok {
+		if raw, err := json.Marshal(v); err == nil {
+			if ts, err := parseTimestamp(raw); err == nil {
+				now = ts
+				delete(data, "ts")
+			}
+		}
+	}
+
+	for key, name := range newRelicFieldNames {
+		val, ok := data[key]
+		if !ok {
+			continue
+		}
+
+		delete(data, key)
+
+		if name != "" {
+			data[name] = val
+		}
+	}
+
+	data["timestamp"] = now.UnixNano() / int64(time.Millisecond)
+
+	if serviceName != "" {
+		data["entity.guid"] = serviceName
+	}
+
+	return data
+}
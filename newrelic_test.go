@@ -0,0 +1,45 @@
+package tracelog
+
+import (
+	"testing"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestWithNewRelicServiceName(t *testing.T) {
+	cfg := &newRelicConfig{serviceName: defaultServiceName()}
+	WithNewRelicServiceName("checkout")(cfg)
+
+	record := newRelicRecord([]byte(`{"msg":"hello"}`), cfg.serviceName)
+	if got := record["entity.guid"]; got != "checkout" {
+		t.Errorf("entity.guid = %v, want checkout", got)
+	}
+}
+
+func TestNewRelicRecordParsesEncodedTime(t *testing.T) {
+	tl, buf := newBufferedLogger(WithTimeEncoder(zapcore.ISO8601TimeEncoder))
+	tl.Info("hello")
+
+	ts, err := time.Parse(iso8601Layout, buf.Entries(t)[0]["ts"].(string))
+	if err != nil {
+		t.Fatalf("failed to parse entry time: %v", err)
+	}
+
+	record := newRelicRecord([]byte(buf.Lines()[0]), "")
+	if want := ts.UnixNano() / int64(time.Millisecond); record["timestamp"] != want {
+		t.Errorf("timestamp = %v, want %d", record["timestamp"], want)
+	}
+
+	if _, ok := record["ts"]; ok {
+		t.Error("record contains the ts field")
+	}
+}
+
+func TestNewRelicRecordKeepsUnparsableTime(t *testing.T) {
+	record := newRelicRecord([]byte(`{"ts":"yesterday","msg":"hello"}`), "")
+
+	if record["ts"] != "yesterday" {
+		t.Errorf("ts = %v, want the unparsable time kept", record["ts"])
+	}
+}
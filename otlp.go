@@ -0,0 +1,277 @@
+package tracelog
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap/zapcore"
+)
+
+// otlpDroppedFields are the keys of entries which are not exported as attributes, as
+// they duplicate the trace context of the log record.
+var otlpDroppedFields = map[string]bool{
+	"dd.traceID": true,
+	"dd.spanID":  true,
+}
+
+type otlpConfig struct {
+	serviceName  string
+	headers      map[string]string
+	batchTimeout time.Duration
+	client       *http.Client
+}
+
+// OTLPOption configures the OTLP logs WriteSyncer.
+type OTLPOption func(*otlpConfig)
+
+// WithOTLPServiceName sets the `service.name` resource attribute of the exported logs.
+// Defaults to the `OTEL_SERVICE_NAME` environment variable, falling back to the name of
+// the running executable.
+func WithOTLPServiceName(name string) OTLPOption {
+	return func(cfg *otlpConfig) {
+		if cfg != nil {
+			cfg.serviceName = name
+		}
+	}
+}
+
+// WithOTLPHeaders sets headers sent with every export request, such as those used to
+// authenticate with a backend.
+func WithOTLPHeaders(headers map[string]string) OTLPOption {
+	return func(cfg *otlpConfig) {
+		if cfg != nil {
+			cfg.headers = headers
+		}
+	}
+}
+
+// WithOTLPBatchTimeout sets the maximum amount of time records are buffered before
+// being exported. Defaults to one second.
+func WithOTLPBatchTimeout(d time.Duration) OTLPOption {
+	return func(cfg *otlpConfig) {
+		if cfg != nil {
+			cfg.batchTimeout = d
+		}
+	}
+}
+
+// NewOTLPLogsWriteSyncer creates a `zapcore.WriteSyncer` that buffers JSON encoded
+// entries and exports them as OTLP log records to the OTLP/HTTP logs endpoint, such as
+// `http://localhost:4318/v1/logs`, using the JSON encoding. The level, message and time
+// of each entry become the severity, body and timestamp of the record, the correlation
+// fields its trace and span IDs, and the remaining fields its attributes. The returned
+// WriteSyncer implements `io.Closer`.
+func NewOTLPLogsWriteSyncer(endpoint string, opts ...OTLPOption) (zapcore.WriteSyncer, error) {
+	if endpoint == "" {
+		return nil, errors.New("OTLP logs endpoint is required")
+	}
+
+	if _, err := url.Parse(endpoint); err != nil {
+		return nil, fmt.Errorf("invalid OTLP logs endpoint: %w", err)
+	}
+
+	cfg := &otlpConfig{
+		serviceName: defaultServiceName(),
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	send := func(entries [][]byte) error {
+		records := make([]map[string]interface{}, 0, len(entries))
+		for _, entry := range entries {
+			records = append(records, otlpLogRecord(entry))
+		}
+
+		body, err := json.Marshal(map[string]interface{}{
+			"resourceLogs": []interface{}{
+				map[string]interface{}{
+					"resource": map[string]interface{}{
+						"attributes": []interface{}{
+							otlpKeyValue("service.name", cfg.serviceName),
+						},
+					},
+					"scopeLogs": []interface{}{
+						map[string]interface{}{
+							"scope":      map[string]interface{}{"name": instrumentationName},
+							"logRecords": records,
+						},
+					},
+				},
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to encode OTLP log records: %w", err)
+		}
+
+		req, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(body))
+		if err != nil {
+			return fmt.Errorf("failed to create OTLP request: %w", err)
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+		for key, val := range cfg.headers {
+			req.Header.Set(key, val)
+		}
+
+		return sendRequest(cfg.client, req)
+	}
+
+	return newBatchSyncer(defaultBatchSize, cfg.batchTimeout, send), nil
+}
+
+// WithOTLPLogs exports entries as OTLP log records to the OTLP/HTTP logs endpoint
+// instead of writing them to stdout. See NewOTLPLogsWriteSyncer.
+func WithOTLPLogs(endpoint string, opts ...OTLPOption) LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl == nil {
+			return
+		}
+
+		ws, err := NewOTLPLogsWriteSyncer(endpoint, opts...)
+		if err != nil {
+			tl.reportError(fmt.Errorf("failed to create OTLP logs output: %w", err))
+
+			return
+		}
+
+		tl.output = ws
+		tl.addCloser(ws)
+	}
+}
+
+// otlpLogRecord converts a JSON encoded entry into an OTLP log record, falling back to
+// exporting the raw entry as the body when it isn't JSON.
+func otlpLogRecord(entry []byte) map[string]interface{} {
+	observed := time.Now()
+
+	parsed, err := ParseLogEntry(entry)
+	if err != nil {
+		return map[string]interface{}{
+			"timeUnixNano":         strconv.FormatInt(observed.UnixNano(), 10),
+			"observedTimeUnixNano": strconv.FormatInt(observed.UnixNano(), 10),
+			"severityNumber":       otlpSeverity(zapcore.InfoLevel),
+			"severityText":         zapcore.InfoLevel.CapitalString(),
+			"body":                 otlpValue(string(bytes.TrimSpace(entry))),
+		}
+	}
+
+	ts := parsed.Timestamp
+	if ts.IsZero() {
+		ts = observed
+	}
+
+	record := map[string]interface{}{
+		"timeUnixNano":         strconv.FormatInt(ts.UnixNano(), 10),
+		"observedTimeUnixNano": strconv.FormatInt(observed.UnixNano(), 10),
+		"severityNumber":       otlpSeverity(parsed.Level),
+		"severityText":         parsed.Level.CapitalString(),
+		"body":                 otlpValue(parsed.Message),
+	}
+
+	var attrs []interface{}
+
+	// The IDs are hex encoded in the OTLP JSON encoding, and are kept as attributes
+	// when they are not valid.
+	if traceID, err := trace.TraceIDFromHex(parsed.TraceID); err == nil {
+		record["traceId"] = traceID.String()
+	} else if parsed.TraceID != "" {
+		attrs = append(attrs, otlpKeyValue(DefaultFieldNames.TraceID, parsed.TraceID))
+	}
+
+	if spanID, err := trace.SpanIDFromHex(parsed.SpanID); err == nil {
+		record["spanId"] = spanID.String()
+	} else if parsed.SpanID != "" {
+		attrs = append(attrs, otlpKeyValue(DefaultFieldNames.SpanID, parsed.SpanID))
+	}
+
+	for key, raw := range parsed.Fields {
+		if otlpDroppedFields[key] {
+			continue
+		}
+
+		var val interface{}
+		if err := json.Unmarshal(raw, &val); err != nil || val == nil {
+			continue
+		}
+
+		attrs = append(attrs, otlpKeyValue(key, val))
+	}
+
+	if len(attrs) > 0 {
+		record["attributes"] = attrs
+	}
+
+	return record
+}
+
+// otlpSeverity maps a zap level to the corresponding OTLP severity number.
+func otlpSeverity(lvl zapcore.Level) int {
+	switch lvl {
+	case zapcore.DebugLevel:
+		return 5
+	case zapcore.InfoLevel:
+		return 9
+	case zapcore.WarnLevel:
+		return 13
+	case zapcore.ErrorLevel:
+		return 17
+	case zapcore.DPanicLevel:
+		return 18
+	case zapcore.PanicLevel:
+		return 21
+	case zapcore.FatalLevel:
+		return 22
+	default:
+		return 0
+	}
+}
+
+// otlpKeyValue encodes an OTLP KeyValue.
+func otlpKeyValue(key string, val interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"key":   key,
+		"value": otlpValue(val),
+	}
+}
+
+// otlpValue encodes a decoded JSON value as an OTLP AnyValue.
+func otlpValue(val interface{}) map[string]interface{} {
+	switch v := val.(type) {
+	case string:
+		return map[string]interface{}{"stringValue": v}
+	case bool:
+		return map[string]interface{}{"boolValue": v}
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1<<53 {
+			return map[string]interface{}{"intValue": strconv.FormatInt(int64(v), 10)}
+		}
+
+		return map[string]interface{}{"doubleValue": v}
+	case []interface{}:
+		values := make([]interface{}, 0, len(v))
+		for _, elem := range v {
+			values = append(values, otlpValue(elem))
+		}
+
+		return map[string]interface{}{"arrayValue": map[string]interface{}{"values": values}}
+	case map[string]interface{}:
+		values := make([]interface{}, 0, len(v))
+		for key, elem := range v {
+			values = append(values, otlpKeyValue(key, elem))
+		}
+
+		return map[string]interface{}{"kvlistValue": map[string]interface{}{"values": values}}
+	default:
+		return map[string]interface{}{"stringValue": fmt.Sprint(v)}
+	}
+}
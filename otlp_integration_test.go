@@ -0,0 +1,156 @@
+//go:build integration
+
+package tracelog
+
+import (
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// collectorImage is the OpenTelemetry Collector image the integration test runs. It is
+// pinned so the format of the debug exporter output the test asserts on is stable.
+const collectorImage = "otel/opentelemetry-collector-contrib:0.98.0"
+
+// collectorConfig receives OTLP/HTTP logs and prints them with the debug exporter.
+const collectorConfig = `receivers:
+  otlp:
+    protocols:
+      http:
+        endpoint: 0.0.0.0:4318
+exporters:
+  debug:
+    verbosity: detailed
+extensions:
+  health_check:
+    endpoint: 0.0.0.0:13133
+service:
+  extensions: [health_check]
+  pipelines:
+    logs:
+      receivers: [otlp]
+      exporters: [debug]
+`
+
+// TestOTLPIntegration exports entries logged within a span to a collector running in
+// Docker and asserts the records printed by its debug exporter carry the trace and span
+// IDs, level and message of each entry. Run it with `go test -tags integration`.
+func TestOTLPIntegration(t *testing.T) {
+	if _, err := exec.LookPath("docker"); err != nil {
+		t.Skip("docker is not available")
+	}
+
+	cfg := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(cfg, []byte(collectorConfig), 0o644); err != nil {
+		t.Fatalf("failed to write collector config: %v", err)
+	}
+
+	id := docker(t, "run", "-d", "-p", "127.0.0.1::4318", "-p", "127.0.0.1::13133",
+		"-v", cfg+":/etc/otelcol-contrib/config.yaml:ro", collectorImage)
+	t.Cleanup(func() {
+		_ = exec.Command("docker", "rm", "-f", id).Run()
+	})
+
+	waitForCollector(t, "http://"+dockerPort(t, id, "13133/tcp"))
+
+	tl := NewLogger(WithOTLPLogs("http://"+dockerPort(t, id, "4318/tcp")+"/v1/logs",
+		WithOTLPServiceName("tracelog-integration"), WithOTLPBatchTimeout(100*time.Millisecond)))
+
+	useSpanRecorder(t)
+
+	sl, span := tl.StartSpan("integration")
+	sl.Info("starting round trip")
+	sl.Warn("round trip slow")
+	sl.Error("round trip failed")
+	span.End()
+
+	if err := tl.Close(); err != nil {
+		t.Fatalf("failed to close logger: %v", err)
+	}
+
+	// Stopping the collector shuts its pipeline down, flushing the debug exporter.
+	docker(t, "stop", id)
+	logs := docker(t, "logs", id)
+
+	// The debug exporter prints each record in a block starting with its index.
+	records := strings.Split(logs, "LogRecord #")
+
+	sc := span.SpanContext()
+	for _, tc := range []struct {
+		body     string
+		severity string
+	}{
+		{body: "starting round trip", severity: "SeverityText: INFO"},
+		{body: "round trip slow", severity: "SeverityText: WARN"},
+		{body: "round trip failed", severity: "SeverityText: ERROR"},
+	} {
+		var record string
+		for _, r := range records {
+			if strings.Contains(r, "Body: Str("+tc.body+")") {
+				record = r
+			}
+		}
+
+		if record == "" {
+			t.Errorf("collector output does not contain a record with body %q:\n%s", tc.body, logs)
+
+			continue
+		}
+
+		for _, want := range []string{
+			tc.severity,
+			"Trace ID: " + sc.TraceID().String(),
+			"Span ID: " + sc.SpanID().String(),
+		} {
+			if !strings.Contains(record, want) {
+				t.Errorf("record %q does not contain %q:\n%s", tc.body, want, record)
+			}
+		}
+	}
+}
+
+// docker runs the docker CLI, failing the test when it fails, and returns its trimmed
+// output.
+func docker(t *testing.T, args ...string) string {
+	t.Helper()
+
+	out, err := exec.Command("docker", args...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("docker %s: %v: %s", strings.Join(args, " "), err, out)
+	}
+
+	return strings.TrimSpace(string(out))
+}
+
+// dockerPort returns the host address the container port is published on.
+func dockerPort(t *testing.T, id, port string) string {
+	t.Helper()
+
+	// `docker port` lists an address per line when the port is bound more than once.
+	return strings.SplitN(docker(t, "port", id, port), "\n", 2)[0]
+}
+
+// waitForCollector polls the health check extension until the collector is ready.
+func waitForCollector(t *testing.T, addr string) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Minute)
+	for time.Now().Before(deadline) {
+		resp, err := http.Get(addr)
+		if err == nil {
+			resp.Body.Close()
+
+			if resp.StatusCode == http.StatusOK {
+				return
+			}
+		}
+
+		time.Sleep(250 * time.Millisecond)
+	}
+
+	t.Fatal("collector did not become ready")
+}
@@ -0,0 +1,129 @@
+package tracelog
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+func TestWithOTLPLogsExportsRecords(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		reqs []map[string]interface{}
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+
+		var req map[string]interface{}
+		if err := json.Unmarshal(body, &req); err != nil {
+			t.Errorf("failed to decode export request %s: %v", body, err)
+		}
+
+		mu.Lock()
+		reqs = append(reqs, req)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	tl := NewLogger(WithOTLPLogs(srv.URL+"/v1/logs", WithOTLPServiceName("checkout")))
+	tl.WarnCtx(contextWithSpan(1, 2), "hello", zap.Int("attempt", 3))
+
+	if err := tl.Close(); err != nil {
+		t.Fatalf("failed to close logger: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if len(reqs) != 1 {
+		t.Fatalf("got %d export requests, want 1", len(reqs))
+	}
+
+	encoded, _ := json.Marshal(reqs[0])
+
+	var export struct {
+		ResourceLogs []struct {
+			Resource struct {
+				Attributes []struct {
+					Key   string
+					Value map[string]interface{}
+				}
+			}
+			ScopeLogs []struct {
+				LogRecords []struct {
+					SeverityNumber int
+					SeverityText   string
+					Body           map[string]interface{}
+					TraceID        string `json:"traceId"`
+					SpanID         string `json:"spanId"`
+					Attributes     []struct {
+						Key   string
+						Value map[string]interface{}
+					}
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal(encoded, &export); err != nil {
+		t.Fatalf("failed to decode export request: %v", err)
+	}
+
+	if len(export.ResourceLogs) != 1 || len(export.ResourceLogs[0].ScopeLogs) != 1 ||
+		len(export.ResourceLogs[0].ScopeLogs[0].LogRecords) != 1 {
+		t.Fatalf("export request %s does not contain a single log record", encoded)
+	}
+
+	if attrs := export.ResourceLogs[0].Resource.Attributes; len(attrs) != 1 ||
+		attrs[0].Key != "service.name" || attrs[0].Value["stringValue"] != "checkout" {
+		t.Errorf("resource attributes = %v, want service.name checkout", attrs)
+	}
+
+	record := export.ResourceLogs[0].ScopeLogs[0].LogRecords[0]
+	if record.SeverityNumber != 13 || record.SeverityText != "WARN" {
+		t.Errorf("severity = %d %s, want 13 WARN", record.SeverityNumber, record.SeverityText)
+	}
+
+	if record.Body["stringValue"] != "hello" {
+		t.Errorf("body = %v, want hello", record.Body)
+	}
+
+	sc := spanContext(1, 2)
+	if record.TraceID != sc.TraceID().String() || record.SpanID != sc.SpanID().String() {
+		t.Errorf("trace context = %s/%s, want %s/%s", record.TraceID, record.SpanID, sc.TraceID(), sc.SpanID())
+	}
+
+	var found bool
+	for _, attr := range record.Attributes {
+		if attr.Key == "attempt" {
+			found = attr.Value["intValue"] == "3"
+		}
+
+		if attr.Key == "dd.traceID" || attr.Key == DefaultFieldNames.TraceID {
+			t.Errorf("record attributes contain %s", attr.Key)
+		}
+	}
+
+	if !found {
+		t.Errorf("record attributes %v do not contain attempt=3", record.Attributes)
+	}
+}
+
+func TestOTLPLogRecordFallsBackToRawBody(t *testing.T) {
+	record := otlpLogRecord([]byte("not json\n"))
+
+	if body := record["body"].(map[string]interface{}); body["stringValue"] != "not json" {
+		t.Errorf("body = %v, want the raw entry", body)
+	}
+}
+
+func TestNewOTLPLogsWriteSyncerRequiresEndpoint(t *testing.T) {
+	if _, err := NewOTLPLogsWriteSyncer(""); err == nil {
+		t.Error("expected an error for a missing endpoint")
+	}
+}
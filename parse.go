@@ -0,0 +1,143 @@
+package tracelog
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// maxParsedLineSize bounds the size of a line read by ParseLogStream.
+const maxParsedLineSize = 1 << 20
+
+// iso8601Layout is the layout used by `zapcore.ISO8601TimeEncoder`.
+const iso8601Layout = "2006-01-02T15:04:05.000Z0700"
+
+// A ParsedEntry is a JSON entry written by a TraceLogger, parsed back into Go types.
+// Fields holds every key other than the level, timestamp, message and the trace and span
+// IDs, such as the caller and the fields of the entry.
+type ParsedEntry struct {
+	Level     zapcore.Level
+	Timestamp time.Time
+	Message   string
+	TraceID   string
+	SpanID    string
+	Fields    map[string]json.RawMessage
+}
+
+// ParseLogEntry parses a JSON entry written using the default encoder config and field
+// names. Timestamps encoded as epoch seconds, milliseconds or nanoseconds, RFC 3339 or
+// ISO 8601 are supported. Entries without a level are parsed at InfoLevel.
+func ParseLogEntry(line []byte) (*ParsedEntry, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(line, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse log entry: %w", err)
+	}
+
+	entry := &ParsedEntry{
+		Level:  zapcore.InfoLevel,
+		Fields: raw,
+	}
+
+	for key, dst := range map[string]*string{
+		"msg":                     &entry.Message,
+		DefaultFieldNames.TraceID: &entry.TraceID,
+		DefaultFieldNames.SpanID:  &entry.SpanID,
+	} {
+		v, ok := raw[key]
+		if !ok {
+			continue
+		}
+
+		if err := json.Unmarshal(v, dst); err != nil {
+			return nil, fmt.Errorf("failed to parse %q of log entry: %w", key, err)
+		}
+
+		delete(raw, key)
+	}
+
+	if v, ok := raw["level"]; ok {
+		var lvl string
+		if err := json.Unmarshal(v, &lvl); err != nil {
+			return nil, fmt.Errorf("failed to parse level of log entry: %w", err)
+		}
+
+		if err := entry.Level.UnmarshalText([]byte(lvl)); err != nil {
+			return nil, fmt.Errorf("failed to parse level of log entry: %w", err)
+		}
+
+		delete(raw, "level")
+	}
+
+	if v, ok := raw["ts"]; ok {
+		ts, err := parseTimestamp(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse timestamp of log entry: %w", err)
+		}
+
+		entry.Timestamp = ts
+		delete(raw, "ts")
+	}
+
+	return entry, nil
+}
+
+// ParseLogStream parses the JSON entries read from r, one per line, skipping empty lines.
+func ParseLogStream(r io.Reader) ([]*ParsedEntry, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxParsedLineSize)
+
+	var entries []*ParsedEntry
+	for n := 1; scanner.Scan(); n++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		entry, err := ParseLogEntry(line)
+		if err != nil {
+			return entries, fmt.Errorf("line %d: %w", n, err)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return entries, fmt.Errorf("failed to read log stream: %w", err)
+	}
+
+	return entries, nil
+}
+
+// parseTimestamp parses a timestamp encoded as an epoch number, inferring the unit from
+// its magnitude, or as an RFC 3339 or ISO 8601 string.
+func parseTimestamp(v json.RawMessage) (time.Time, error) {
+	var epoch float64
+	if err := json.Unmarshal(v, &epoch); err == nil {
+		switch {
+		case epoch > 1e17:
+			return time.Unix(0, int64(epoch)), nil
+		case epoch > 1e11:
+			return time.UnixMilli(int64(epoch)), nil
+		default:
+			sec, frac := math.Modf(epoch)
+			return time.Unix(int64(sec), int64(frac*1e9)), nil
+		}
+	}
+
+	var s string
+	if err := json.Unmarshal(v, &s); err != nil {
+		return time.Time{}, err
+	}
+
+	if ts, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return ts, nil
+	}
+
+	return time.Parse(iso8601Layout, s)
+}
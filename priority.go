@@ -0,0 +1,250 @@
+package tracelog
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// priorityItem is an entry queued for the core it should be written to.
+type priorityItem struct {
+	core   zapcore.Core
+	ent    zapcore.Entry
+	fields []zapcore.Field
+}
+
+// priorityState is shared by a priority core and the cores derived from it using With.
+type priorityState struct {
+	underlying zapcore.Core
+	high       chan priorityItem
+	low        chan priorityItem
+	flush      chan chan error
+	done       chan struct{}
+	stopped    chan struct{}
+	once       sync.Once
+
+	mu   sync.Mutex
+	errs error
+}
+
+// priorityAsyncCore writes entries in the background, prioritizing high severity entries.
+type priorityAsyncCore struct {
+	zapcore.Core
+	state *priorityState
+}
+
+// NewPriorityAsyncCore creates a `zapcore.Core` that writes entries to underlying in the
+// background using two queues. Error, DPanic, Panic and Fatal entries are queued in a
+// high priority queue of highCap entries, which is always drained first and blocks the
+// caller when full, so they are never dropped. Debug, Info and Warn entries are queued
+// in a low priority queue of lowCap entries, and are dropped when it is full. Entries
+// above ErrorLevel are flushed before returning, as the program may be about to exit.
+// Values which are encoded by calling into them, such as objects and errors, are encoded
+// before the entry is queued. The returned core implements `io.Closer`.
+func NewPriorityAsyncCore(underlying zapcore.Core, highCap, lowCap int) zapcore.Core {
+	state := &priorityState{
+		underlying: underlying,
+		high:       make(chan priorityItem, highCap),
+		low:        make(chan priorityItem, lowCap),
+		flush:      make(chan chan error),
+		done:       make(chan struct{}),
+		stopped:    make(chan struct{}),
+	}
+
+	go state.run()
+
+	return &priorityAsyncCore{
+		Core:  underlying,
+		state: state,
+	}
+}
+
+func (c *priorityAsyncCore) With(fields []zapcore.Field) zapcore.Core {
+	return &priorityAsyncCore{
+		Core:  c.Core.With(fields),
+		state: c.state,
+	}
+}
+
+func (c *priorityAsyncCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+
+	return ce
+}
+
+func (c *priorityAsyncCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	select {
+	case <-c.state.done:
+		if ent.Level < zapcore.ErrorLevel {
+			return nil
+		}
+
+		return errors.New("priority async core is closed")
+	default:
+	}
+
+	item := priorityItem{
+		core:   c.Core,
+		ent:    ent,
+		fields: snapshotFields(fields),
+	}
+
+	if ent.Level < zapcore.ErrorLevel {
+		select {
+		case c.state.low <- item:
+		default:
+		}
+
+		return nil
+	}
+
+	select {
+	case c.state.high <- item:
+	case <-c.state.done:
+		return errors.New("priority async core is closed")
+	}
+
+	if ent.Level > zapcore.ErrorLevel {
+		return c.Sync()
+	}
+
+	return nil
+}
+
+// Sync waits for the queued entries to be written, then syncs the underlying core.
+func (c *priorityAsyncCore) Sync() error {
+	reply := make(chan error)
+
+	select {
+	case c.state.flush <- reply:
+	case <-c.state.done:
+		return nil
+	}
+
+	return <-reply
+}
+
+// Close writes the queued entries and stops the background goroutine, then syncs the
+// underlying core. Entries written once closed are dropped, returning an error for
+// entries at ErrorLevel and above.
+func (c *priorityAsyncCore) Close() error {
+	s := c.state
+
+	s.once.Do(func() {
+		close(s.done)
+	})
+
+	<-s.stopped
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := multierr.Append(s.errs, s.underlying.Sync())
+	s.errs = nil
+
+	return err
+}
+
+func (s *priorityState) run() {
+	defer close(s.stopped)
+
+	for {
+		// Always prefer high priority entries when both queues have entries.
+		select {
+		case item := <-s.high:
+			s.write(item)
+
+			continue
+		default:
+		}
+
+		select {
+		case item := <-s.high:
+			s.write(item)
+		case item := <-s.low:
+			s.write(item)
+		case reply := <-s.flush:
+			s.drain()
+
+			s.mu.Lock()
+			err := multierr.Append(s.errs, s.underlying.Sync())
+			s.errs = nil
+			s.mu.Unlock()
+
+			reply <- err
+		case <-s.done:
+			s.drain()
+
+			return
+		}
+	}
+}
+
+// drain writes every queued entry, high priority entries first.
+func (s *priorityState) drain() {
+	for {
+		select {
+		case item := <-s.high:
+			s.write(item)
+
+			continue
+		default:
+		}
+
+		select {
+		case item := <-s.low:
+			s.write(item)
+		default:
+			return
+		}
+	}
+}
+
+func (s *priorityState) write(item priorityItem) {
+	if err := item.core.Write(item.ent, item.fields); err != nil {
+		s.mu.Lock()
+		s.errs = multierr.Append(s.errs, err)
+		s.mu.Unlock()
+	}
+}
+
+// snapshotFields returns a copy of fields in which the values encoded by calling into
+// them, such as objects, errors and Stringers, are replaced by their encoded form, so
+// they cannot change while the entry is queued.
+func snapshotFields(fields []zapcore.Field) []zapcore.Field {
+	snapshot := make([]zapcore.Field, 0, len(fields))
+
+	for _, f := range fields {
+		switch f.Type {
+		case zapcore.ArrayMarshalerType, zapcore.ObjectMarshalerType, zapcore.InlineMarshalerType,
+			zapcore.ReflectType, zapcore.StringerType, zapcore.ErrorType:
+			enc := zapcore.NewMapObjectEncoder()
+			f.AddTo(enc)
+
+			// Inline objects and errors may add several keys.
+			keys := make([]string, 0, len(enc.Fields))
+			for key := range enc.Fields {
+				keys = append(keys, key)
+			}
+
+			sort.Strings(keys)
+
+			for _, key := range keys {
+				snapshot = append(snapshot, zap.Any(key, enc.Fields[key]))
+			}
+		case zapcore.BinaryType, zapcore.ByteStringType:
+			f.Interface = append([]byte(nil), f.Interface.([]byte)...)
+			snapshot = append(snapshot, f)
+		default:
+			snapshot = append(snapshot, f)
+		}
+	}
+
+	return snapshot
+}
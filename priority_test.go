@@ -0,0 +1,81 @@
+package tracelog
+
+import (
+	"io"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// counter is an object whose encoding changes as it is incremented.
+type counter struct {
+	n int
+}
+
+func (c *counter) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddInt("n", c.n)
+
+	return nil
+}
+
+func TestPriorityAsyncCoreEncodesFieldsBeforeQueueing(t *testing.T) {
+	observed, logs := observer.New(zapcore.DebugLevel)
+	core := NewPriorityAsyncCore(observed, 8, 8)
+	lg := zap.New(core)
+
+	c := &counter{n: 1}
+	lg.Info("msg", zap.Object("counter", c))
+	c.n = 2
+
+	if err := core.Sync(); err != nil {
+		t.Fatalf("failed to sync: %v", err)
+	}
+
+	got, ok := logs.All()[0].ContextMap()["counter"].(map[string]interface{})
+	if !ok || got["n"] != 1 {
+		t.Errorf("counter = %v, want the value when logged", logs.All()[0].ContextMap()["counter"])
+	}
+}
+
+func TestPriorityAsyncCoreClose(t *testing.T) {
+	observed, logs := observer.New(zapcore.DebugLevel)
+	core := NewPriorityAsyncCore(observed, 8, 8)
+	lg := zap.New(core)
+
+	lg.Info("queued")
+	lg.Error("queued error")
+
+	if err := core.(io.Closer).Close(); err != nil {
+		t.Fatalf("failed to close: %v", err)
+	}
+
+	if n := logs.Len(); n != 2 {
+		t.Errorf("got %d entries, want the queued entries written on Close", n)
+	}
+
+	if err := core.Write(zapcore.Entry{Level: zapcore.ErrorLevel}, nil); err == nil {
+		t.Error("wrote an error entry once closed, want an error")
+	}
+
+	if err := core.Sync(); err != nil {
+		t.Errorf("Sync once closed: %v", err)
+	}
+}
+
+func TestSnapshotFieldsCopiesByteSlices(t *testing.T) {
+	binary, text := []byte("binary"), []byte("text")
+	snapshot := snapshotFields([]zapcore.Field{zap.Binary("binary", binary), zap.ByteString("text", text)})
+
+	copy(binary, "BINARY")
+	copy(text, "TEXT")
+
+	if got := string(snapshot[0].Interface.([]byte)); got != "binary" {
+		t.Errorf("binary field = %q, want the value when snapshotted", got)
+	}
+
+	if got := string(snapshot[1].Interface.([]byte)); got != "text" {
+		t.Errorf("byte string field = %q, want the value when snapshotted", got)
+	}
+}
@@ -0,0 +1,6 @@
+//go:build race
+
+package tracelog
+
+// raceEnabled reports whether the race detector is enabled, which adds allocations.
+const raceEnabled = true
@@ -0,0 +1,129 @@
+package tracelog
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/time/rate"
+)
+
+// rateLimiterPruneInterval is how often limiters for messages that have not been
+// seen recently are removed.
+const rateLimiterPruneInterval = 5 * time.Minute
+
+// rateLimiterState is shared by a rate limiting core and the cores derived from it using With.
+type rateLimiterState struct {
+	limit rate.Limit
+	burst int
+
+	mu       sync.Mutex
+	limiters map[string]*messageLimiter
+
+	dropped uint64
+	once    sync.Once
+	done    chan struct{}
+}
+
+type messageLimiter struct {
+	*rate.Limiter
+	lastSeen time.Time
+}
+
+// rateLimiterCore limits the rate of entries per unique message.
+type rateLimiterCore struct {
+	zapcore.Core
+	state *rateLimiterState
+}
+
+// NewPerMessageRateLimiterCore creates a `zapcore.Core` that writes at most r entries per
+// second for each unique message, allowing bursts of up to burst entries. Entries
+// exceeding the rate are dropped and counted. Limiters for messages not seen within
+// five minutes are pruned in the background. The returned core implements `io.Closer`
+// to stop pruning, and `Dropped() uint64` to report the number of dropped entries.
+func NewPerMessageRateLimiterCore(underlying zapcore.Core, r float64, burst int) zapcore.Core {
+	state := &rateLimiterState{
+		limit:    rate.Limit(r),
+		burst:    burst,
+		limiters: make(map[string]*messageLimiter),
+		done:     make(chan struct{}),
+	}
+
+	go state.prune()
+
+	return &rateLimiterCore{
+		Core:  underlying,
+		state: state,
+	}
+}
+
+func (c *rateLimiterCore) With(fields []zapcore.Field) zapcore.Core {
+	return &rateLimiterCore{
+		Core:  c.Core.With(fields),
+		state: c.state,
+	}
+}
+
+func (c *rateLimiterCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.Enabled(ent.Level) {
+		return ce
+	}
+
+	if !c.state.allow(ent.Message, time.Now()) {
+		atomic.AddUint64(&c.state.dropped, 1)
+
+		return ce
+	}
+
+	return c.Core.Check(ent, ce)
+}
+
+// Dropped returns the number of entries dropped for exceeding the rate.
+func (c *rateLimiterCore) Dropped() uint64 {
+	return atomic.LoadUint64(&c.state.dropped)
+}
+
+// Close stops pruning limiters in the background.
+func (c *rateLimiterCore) Close() error {
+	c.state.once.Do(func() {
+		close(c.state.done)
+	})
+
+	return nil
+}
+
+func (s *rateLimiterState) allow(msg string, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lim, ok := s.limiters[msg]
+	if !ok {
+		lim = &messageLimiter{Limiter: rate.NewLimiter(s.limit, s.burst)}
+		s.limiters[msg] = lim
+	}
+
+	lim.lastSeen = now
+
+	return lim.AllowN(now, 1)
+}
+
+func (s *rateLimiterState) prune() {
+	ticker := time.NewTicker(rateLimiterPruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			s.mu.Lock()
+			for msg, lim := range s.limiters {
+				if now.Sub(lim.lastSeen) > rateLimiterPruneInterval {
+					delete(s.limiters, msg)
+				}
+			}
+			s.mu.Unlock()
+		case <-s.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package tracelog
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"strings"
+
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// WithRepanic re-raises panics after they have been logged by Recover. By default
+// Recover swallows the panic.
+func WithRepanic() LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl != nil {
+			tl.repanic = true
+		}
+	}
+}
+
+// Recover recovers from a panic, logging it at ErrorLevel with the stack trace and
+// recording it as an error on the span in the provided `context.Context`. It must be
+// called directly using `defer`:
+//
+//	defer lg.Recover(ctx)
+//
+// The panic is swallowed unless the logger was created using WithRepanic.
+func (tl *TraceLogger) Recover(ctx context.Context) {
+	rec := recover()
+	if rec == nil {
+		return
+	}
+
+	tl.logPanic(ctx, "recovered from panic", rec)
+
+	if tl.repanic {
+		panic(rec)
+	}
+}
+
+// logPanic logs the recovered value and marks the span in ctx as errored. The entry
+// reports the function which panicked as its caller. It must be called by the deferred
+// function which recovered.
+func (tl *TraceLogger) logPanic(ctx context.Context, msg string, rec interface{}) {
+	err := panicError(rec)
+
+	tl.Rebind(ctx).WithCallerSkip(panicCallerSkip()).Error(
+		msg,
+		zap.Any("panic", rec),
+		zap.Stack("stack"),
+	)
+
+	span := trace.SpanFromContext(ctx)
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// panicError formats the value recovered from a panic as an error.
+func panicError(rec interface{}) error {
+	if err, ok := rec.(error); ok {
+		return err
+	}
+
+	return fmt.Errorf("%v", rec)
+}
+
+// panicCallerSkip returns the number of callers to skip from the caller of
+// panicCallerSkip to reach the function which panicked, found as the first frame
+// outside the runtime below `runtime.gopanic`. When the panic frame cannot be found,
+// only the caller of panicCallerSkip is skipped.
+func panicCallerSkip() int {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+
+	panicking := false
+	for skip := 0; ; skip++ {
+		frame, more := frames.Next()
+
+		switch {
+		case frame.Function == "runtime.gopanic":
+			panicking = true
+		case panicking && !strings.HasPrefix(frame.Function, "runtime."):
+			return skip
+		}
+
+		if !more {
+			return 1
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package tracelog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// panicLine returns the caller as encoded in entries for the line following its call.
+func panicLine() string {
+	pc, file, line, ok := runtime.Caller(1)
+
+	return zapcore.NewEntryCaller(pc, file, line+1, ok).TrimmedPath()
+}
+
+func TestRecoverReportsPanickingCaller(t *testing.T) {
+	tl, buf := newBufferedLogger()
+	ctx := contextWithSpan(1, 1)
+
+	var want string
+	func() {
+		defer tl.SetContext(ctx).Recover(ctx)
+
+		want = panicLine()
+		panic("boom")
+	}()
+
+	lines := buf.Lines()
+	if len(lines) != 1 {
+		t.Fatalf("got %d entries, want 1", len(lines))
+	}
+
+	assertUniqueKeys(t, lines[0], correlationKeys...)
+
+	if got := buf.Entries(t)[0]["caller"]; got != want {
+		t.Errorf("caller = %v, want %s", got, want)
+	}
+}
+
+func TestRecoverReportsRuntimePanicCaller(t *testing.T) {
+	tl, buf := newBufferedLogger()
+	ctx := contextWithSpan(1, 1)
+
+	var want string
+	func() {
+		defer tl.Recover(ctx)
+
+		var m map[string]int
+		want = panicLine()
+		m["key"]++
+	}()
+
+	if got := buf.Entries(t)[0]["caller"]; got != want {
+		t.Errorf("caller = %v, want %s", got, want)
+	}
+}
+
+func TestRecoveryMiddlewareReportsPanickingCaller(t *testing.T) {
+	tl, buf := newBufferedLogger()
+
+	var want string
+	h := Middleware(tl)(RecoveryMiddleware(tl)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		want = panicLine()
+		panic("boom")
+	})))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if rec.Code != http.StatusInternalServerError {
+		t.Errorf("status = %d, want %d", rec.Code, http.StatusInternalServerError)
+	}
+
+	lines := buf.Lines()
+	assertUniqueKeys(t, lines[0], correlationKeys...)
+
+	if got := buf.Entries(t)[0]["caller"]; got != want {
+		t.Errorf("caller = %v, want %s", got, want)
+	}
+}
+
+func TestRecoveryMiddlewareDelegatesFlush(t *testing.T) {
+	tl, _ := newBufferedLogger()
+
+	h := RecoveryMiddleware(tl)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		f, ok := w.(http.Flusher)
+		if !ok {
+			t.Fatal("response writer does not implement http.Flusher")
+		}
+
+		f.Flush()
+		panic("boom")
+	}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if !rec.Flushed {
+		t.Error("flush was not delegated to the wrapped writer")
+	}
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d once the response was flushed", rec.Code, http.StatusOK)
+	}
+}
+
+func TestRecoveryMiddlewareHijackUnsupported(t *testing.T) {
+	tl, _ := newBufferedLogger()
+
+	var err error
+	h := RecoveryMiddleware(tl)(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _, err = w.(http.Hijacker).Hijack()
+	}))
+
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if err == nil {
+		t.Error("expected an error hijacking a writer without hijacking support")
+	}
+}
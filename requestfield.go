@@ -0,0 +1,57 @@
+package tracelog
+
+import (
+	"net/http"
+	"strings"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// DefaultRequestFieldHeaders are the headers RequestField logs when no headers are
+// provided. Headers which may carry credentials, such as Authorization and Cookie, are
+// excluded.
+var DefaultRequestFieldHeaders = []string{"Content-Type", "User-Agent"}
+
+// RequestField returns a `request` field encoding the method, path and query of r, along
+// with the values of the allowed headers present on r, defaulting to
+// DefaultRequestFieldHeaders. Only allow headers which are safe to write to the logs.
+func RequestField(r *http.Request, headers ...string) zap.Field {
+	if len(headers) == 0 {
+		headers = DefaultRequestFieldHeaders
+	}
+
+	return zap.Object("request", requestMarshaler{r: r, headers: headers})
+}
+
+// requestMarshaler encodes a request as a nested object.
+type requestMarshaler struct {
+	r       *http.Request
+	headers []string
+}
+
+func (m requestMarshaler) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	if m.r == nil {
+		return nil
+	}
+
+	enc.AddString("method", m.r.Method)
+
+	if m.r.URL != nil {
+		enc.AddString("path", m.r.URL.Path)
+
+		if m.r.URL.RawQuery != "" {
+			enc.AddString("query", m.r.URL.RawQuery)
+		}
+	}
+
+	return enc.AddObject("headers", zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+		for _, h := range m.headers {
+			if vals := m.r.Header.Values(h); len(vals) > 0 {
+				enc.AddString(http.CanonicalHeaderKey(h), strings.Join(vals, ", "))
+			}
+		}
+
+		return nil
+	}))
+}
@@ -0,0 +1,99 @@
+package tracelog
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/http"
+)
+
+// requestIDField is the field carrying the request ID added by the Middleware.
+const requestIDField = "requestID"
+
+// WithRequestIDKey reads the request ID from the request header headerName, generating
+// one when the header is missing, and adds it to the entries of the request's logger as
+// the `requestID` field. The request ID is returned to the client in the same response
+// header, see RequestIDResponseWriter.
+func WithRequestIDKey(headerName string) MiddlewareOption {
+	return func(cfg *middlewareConfig) {
+		if cfg != nil {
+			cfg.requestIDHeader = headerName
+		}
+	}
+}
+
+// RequestIDResponseWriter wraps w to set the headerName response header to requestID
+// when the response is started, on the first call to WriteHeader or Write, unless the
+// handler has already set the header. Flush and Hijack are delegated to w when it
+// supports them.
+func RequestIDResponseWriter(w http.ResponseWriter, requestID, headerName string) http.ResponseWriter {
+	return &requestIDWriter{
+		ResponseWriter: w,
+		requestID:      requestID,
+		header:         headerName,
+	}
+}
+
+// requestIDWriter sets the request ID response header when the response is started.
+type requestIDWriter struct {
+	http.ResponseWriter
+	requestID string
+	header    string
+	started   bool
+}
+
+func (w *requestIDWriter) start() {
+	if w.started {
+		return
+	}
+
+	w.started = true
+
+	if h := w.Header(); h.Get(w.header) == "" {
+		h.Set(w.header, w.requestID)
+	}
+}
+
+func (w *requestIDWriter) WriteHeader(code int) {
+	w.start()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *requestIDWriter) Write(b []byte) (int, error) {
+	w.start()
+
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *requestIDWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		w.start()
+		f.Flush()
+	}
+}
+
+func (w *requestIDWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+
+	return h.Hijack()
+}
+
+// requestID returns the request ID found in the header of r, generating a random ID
+// when there is none.
+func requestID(r *http.Request, header string) string {
+	if id := r.Header.Get(header); id != "" {
+		return id
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+
+	return hex.EncodeToString(b)
+}
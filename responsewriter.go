@@ -0,0 +1,92 @@
+package tracelog
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+// ResponseWriter wraps an `http.ResponseWriter`, recording the status code and the
+// number of bytes written so they can be logged once the handler has returned.
+type ResponseWriter struct {
+	http.ResponseWriter
+	status  int
+	written int64
+}
+
+// NewResponseWriter wraps the provided `http.ResponseWriter`. Flush and Hijack are
+// delegated to w when it supports them.
+func NewResponseWriter(w http.ResponseWriter) *ResponseWriter {
+	return &ResponseWriter{ResponseWriter: w}
+}
+
+// WriteHeader records the status code before writing it.
+func (w *ResponseWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write counts the bytes written, recording an implicit `http.StatusOK` status when
+// WriteHeader has not been called.
+func (w *ResponseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	n, err := w.ResponseWriter.Write(b)
+	w.written += int64(n)
+
+	return n, err
+}
+
+// Flush implements `http.Flusher`, doing nothing when the wrapped writer does not
+// support flushing.
+func (w *ResponseWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+
+		f.Flush()
+	}
+}
+
+// Hijack implements `http.Hijacker`, returning an error when the wrapped writer does
+// not support hijacking.
+func (w *ResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+
+	return h.Hijack()
+}
+
+// StatusCode returns the status code written, defaulting to `http.StatusOK` as the
+// `http.Server` does when nothing has been written.
+func (w *ResponseWriter) StatusCode() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+
+	return w.status
+}
+
+// BytesWritten returns the number of bytes of the response body written.
+func (w *ResponseWriter) BytesWritten() int64 {
+	return w.written
+}
+
+// Fields returns the status code and bytes written as the `status` and `size` fields.
+func (w *ResponseWriter) Fields() []zap.Field {
+	return []zap.Field{
+		zap.Int("status", w.StatusCode()),
+		zap.Int64("size", w.written),
+	}
+}
@@ -0,0 +1,252 @@
+package tracelog
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+)
+
+// SemconvVersion identifies the version of the OpenTelemetry semantic conventions used
+// for the HTTP attributes set on spans.
+type SemconvVersion int
+
+const (
+	// SemconvV1_4 uses the `http.method`, `http.target` and `net.*` attributes of
+	// semantic conventions v1.4.0. This is the default.
+	SemconvV1_4 SemconvVersion = iota
+	// SemconvV1_20 uses the `http.request.method`, `url.*`, `server.*` and `client.*`
+	// attributes of semantic conventions v1.20.0, with the body sizes recorded as
+	// `http.request_content_length` and `http.response_content_length`, and the protocol
+	// version as `net.protocol.version`.
+	SemconvV1_20
+	// SemconvV1_21 uses the attributes of SemconvV1_20 as renamed by the stable HTTP
+	// semantic conventions v1.21.0, recording the body sizes as `http.request.body.size`
+	// and `http.response.body.size`, and the protocol version as
+	// `network.protocol.version`.
+	SemconvV1_21
+)
+
+// The attributes of the HTTP semantic conventions v1.20.0 and later, which are not
+// available in the semconv package of the OpenTelemetry version in use.
+const (
+	httpRequestMethodKey      = attribute.Key("http.request.method")
+	httpResponseStatusCodeKey = attribute.Key("http.response.status_code")
+	httpRouteKey              = attribute.Key("http.route")
+	urlFullKey                = attribute.Key("url.full")
+	urlPathKey                = attribute.Key("url.path")
+	urlQueryKey               = attribute.Key("url.query")
+	urlSchemeKey              = attribute.Key("url.scheme")
+	serverAddressKey          = attribute.Key("server.address")
+	serverPortKey             = attribute.Key("server.port")
+	clientAddressKey          = attribute.Key("client.address")
+	userAgentOriginalKey      = attribute.Key("user_agent.original")
+
+	// Renamed in v1.21.0.
+	httpRequestContentLengthKey  = attribute.Key("http.request_content_length")
+	httpResponseContentLengthKey = attribute.Key("http.response_content_length")
+	netProtocolVersionKey        = attribute.Key("net.protocol.version")
+
+	// Introduced in v1.21.0.
+	httpRequestBodySizeKey    = attribute.Key("http.request.body.size")
+	httpResponseBodySizeKey   = attribute.Key("http.response.body.size")
+	networkProtocolVersionKey = attribute.Key("network.protocol.version")
+)
+
+// WithSemconvVersion sets the version of the semantic conventions used for the HTTP
+// attributes set by WithRequest, TagClientRequest, TagServerRequest and Middleware,
+// defaulting to SemconvV1_4.
+// Select SemconvV1_20 or later for backends expecting the stable attribute names.
+func WithSemconvVersion(ver SemconvVersion) LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl != nil {
+			tl.semconvVersion = ver
+		}
+	}
+}
+
+// serverRequestAttributes returns the attributes describing an inbound request.
+func (tl *TraceLogger) serverRequestAttributes(r *http.Request) []attribute.KeyValue {
+	if tl.semconvVersion < SemconvV1_20 {
+		attrs := semconv.NetAttributesFromHTTPRequest("tcp", r)
+		attrs = append(attrs, semconv.EndUserAttributesFromHTTPRequest(r)...)
+
+		return append(attrs, semconv.HTTPServerAttributesFromHTTPRequest("", "", r)...)
+	}
+
+	attrs := []attribute.KeyValue{
+		httpRequestMethodKey.String(requestMethod(r)),
+		urlPathKey.String(r.URL.Path),
+		urlSchemeKey.String(requestScheme(r)),
+	}
+
+	if r.URL.RawQuery != "" {
+		attrs = append(attrs, urlQueryKey.String(r.URL.RawQuery))
+	}
+
+	attrs = append(attrs, hostAttributes(r.Host)...)
+
+	if values := r.Header["X-Forwarded-For"]; len(values) > 0 {
+		attrs = append(attrs, clientAddressKey.String(strings.TrimSpace(strings.Split(values[0], ",")[0])))
+	} else if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		attrs = append(attrs, clientAddressKey.String(host))
+	}
+
+	attrs = append(attrs, semconv.EndUserAttributesFromHTTPRequest(r)...)
+
+	return append(attrs, tl.commonRequestAttributes(r)...)
+}
+
+// clientRequestAttributes returns the attributes describing an outbound request.
+func (tl *TraceLogger) clientRequestAttributes(r *http.Request) []attribute.KeyValue {
+	if tl.semconvVersion < SemconvV1_20 {
+		return semconv.HTTPClientAttributesFromHTTPRequest(r)
+	}
+
+	// Credentials in the URL are not recorded.
+	u := *r.URL
+	u.User = nil
+
+	host := r.Host
+	if host == "" {
+		host = r.URL.Host
+	}
+
+	attrs := []attribute.KeyValue{
+		httpRequestMethodKey.String(requestMethod(r)),
+		urlFullKey.String(u.String()),
+	}
+
+	attrs = append(attrs, hostAttributes(host)...)
+
+	return append(attrs, tl.commonRequestAttributes(r)...)
+}
+
+// statusCodeAttribute returns the attribute recording the response status code.
+func (tl *TraceLogger) statusCodeAttribute(code int) attribute.KeyValue {
+	if tl.semconvVersion < SemconvV1_20 {
+		return semconv.HTTPStatusCodeKey.Int(code)
+	}
+
+	return httpResponseStatusCodeKey.Int(code)
+}
+
+// responseSizeAttribute returns the attribute recording the size of the response body.
+func (tl *TraceLogger) responseSizeAttribute(size int64) attribute.KeyValue {
+	switch {
+	case tl.semconvVersion < SemconvV1_20:
+		return semconv.HTTPResponseContentLengthKey.Int64(size)
+	case tl.semconvVersion < SemconvV1_21:
+		return httpResponseContentLengthKey.Int64(size)
+	default:
+		return httpResponseBodySizeKey.Int64(size)
+	}
+}
+
+// routeAttribute returns the attribute recording the matched route, which is named
+// `http.route` in every supported version.
+func (tl *TraceLogger) routeAttribute(route string) attribute.KeyValue {
+	if tl.semconvVersion < SemconvV1_20 {
+		return semconv.HTTPRouteKey.String(route)
+	}
+
+	return httpRouteKey.String(route)
+}
+
+// accessAttributes returns the attributes describing a served request for AccessLog.
+func (tl *TraceLogger) accessAttributes(r *http.Request, status int, size int64, clientIP string) []attribute.KeyValue {
+	if tl.semconvVersion < SemconvV1_20 {
+		return []attribute.KeyValue{
+			semconv.HTTPMethodKey.String(r.Method),
+			semconv.HTTPTargetKey.String(r.URL.RequestURI()),
+			semconv.HTTPStatusCodeKey.Int(status),
+			semconv.HTTPResponseContentLengthKey.Int64(size),
+			semconv.HTTPUserAgentKey.String(r.UserAgent()),
+			semconv.HTTPClientIPKey.String(clientIP),
+		}
+	}
+
+	attrs := []attribute.KeyValue{
+		httpRequestMethodKey.String(requestMethod(r)),
+		urlPathKey.String(r.URL.Path),
+	}
+
+	if r.URL.RawQuery != "" {
+		attrs = append(attrs, urlQueryKey.String(r.URL.RawQuery))
+	}
+
+	return append(
+		attrs,
+		tl.statusCodeAttribute(status),
+		tl.responseSizeAttribute(size),
+		userAgentOriginalKey.String(r.UserAgent()),
+		clientAddressKey.String(clientIP),
+	)
+}
+
+// hostAttributes returns the server address and port found in host.
+func hostAttributes(host string) []attribute.KeyValue {
+	if host == "" {
+		return nil
+	}
+
+	name, port, err := net.SplitHostPort(host)
+	if err != nil {
+		return []attribute.KeyValue{serverAddressKey.String(host)}
+	}
+
+	attrs := []attribute.KeyValue{serverAddressKey.String(name)}
+	if p, err := strconv.Atoi(port); err == nil {
+		attrs = append(attrs, serverPortKey.Int(p))
+	}
+
+	return attrs
+}
+
+// commonRequestAttributes returns the attributes of semantic conventions v1.20.0 and
+// later shared by inbound and outbound requests.
+func (tl *TraceLogger) commonRequestAttributes(r *http.Request) []attribute.KeyValue {
+	bodySizeKey, protocolVersionKey := httpRequestBodySizeKey, networkProtocolVersionKey
+	if tl.semconvVersion < SemconvV1_21 {
+		bodySizeKey, protocolVersionKey = httpRequestContentLengthKey, netProtocolVersionKey
+	}
+
+	var attrs []attribute.KeyValue
+	if ua := r.UserAgent(); ua != "" {
+		attrs = append(attrs, userAgentOriginalKey.String(ua))
+	}
+
+	if r.ContentLength > 0 {
+		attrs = append(attrs, bodySizeKey.Int64(r.ContentLength))
+	}
+
+	switch r.ProtoMajor {
+	case 1:
+		attrs = append(attrs, protocolVersionKey.String("1."+strconv.Itoa(r.ProtoMinor)))
+	case 2, 3:
+		attrs = append(attrs, protocolVersionKey.String(strconv.Itoa(r.ProtoMajor)))
+	}
+
+	return attrs
+}
+
+// requestMethod returns the method of the request, which defaults to GET when empty.
+func requestMethod(r *http.Request) string {
+	if r.Method == "" {
+		return http.MethodGet
+	}
+
+	return r.Method
+}
+
+// requestScheme returns the scheme used by an inbound request.
+func requestScheme(r *http.Request) string {
+	if r.TLS != nil {
+		return "https"
+	}
+
+	return "http"
+}
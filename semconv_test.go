@@ -0,0 +1,80 @@
+package tracelog
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// attributeKeys returns the set of keys of attrs.
+func attributeKeys(attrs []attribute.KeyValue) map[attribute.Key]bool {
+	keys := make(map[attribute.Key]bool, len(attrs))
+	for _, attr := range attrs {
+		keys[attr.Key] = true
+	}
+
+	return keys
+}
+
+func TestSemconvVersionKeys(t *testing.T) {
+	tests := []struct {
+		name    string
+		version SemconvVersion
+		want    []attribute.Key
+		absent  []attribute.Key
+	}{
+		{
+			name:    "v1.4",
+			version: SemconvV1_4,
+			want:    []attribute.Key{"http.method", "http.target", "http.status_code", "http.response_content_length", "http.route"},
+			absent:  []attribute.Key{"http.request.method", "url.path"},
+		},
+		{
+			name:    "v1.20",
+			version: SemconvV1_20,
+			want:    []attribute.Key{"http.request.method", "url.path", "http.response.status_code", "http.request_content_length", "http.response_content_length", "net.protocol.version", "http.route"},
+			absent:  []attribute.Key{"http.request.body.size", "http.response.body.size", "network.protocol.version", "http.method"},
+		},
+		{
+			name:    "v1.21",
+			version: SemconvV1_21,
+			want:    []attribute.Key{"http.request.method", "url.path", "http.response.status_code", "http.request.body.size", "http.response.body.size", "network.protocol.version", "http.route"},
+			absent:  []attribute.Key{"http.request_content_length", "http.response_content_length", "net.protocol.version", "http.method"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			rec := useSpanRecorder(t)
+			tl, _ := newBufferedLogger(WithSemconvVersion(tt.version))
+
+			lg, span := tl.SetContext(context.Background()).StartSpan("request")
+
+			r := httptest.NewRequest(http.MethodPost, "/users/1?expand=true", strings.NewReader("body"))
+			lg.TagServerRequest(lg.Context(), r)
+			lg.TagRoute(lg.Context(), "/users/{id}")
+			lg.AccessLog(r, http.StatusOK, 42, time.Millisecond)
+			span.End()
+
+			keys := attributeKeys(rec.Ended()[0].Attributes())
+			for _, key := range tt.want {
+				if !keys[key] {
+					t.Errorf("missing attribute %s in %v", key, keys)
+				}
+			}
+
+			for _, key := range tt.absent {
+				if keys[key] {
+					t.Errorf("unexpected attribute %s", key)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package tracelog
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const signatureKey = `"_sig":"`
+
+var errNotJSONObject = errors.New("entry is not a JSON object")
+
+// signedWriteSyncer appends an HMAC-SHA256 signature to each JSON encoded entry.
+type signedWriteSyncer struct {
+	underlying zapcore.WriteSyncer
+	hashers    sync.Pool
+}
+
+// NewSignedWriteSyncer creates a `zapcore.WriteSyncer` that computes the HMAC-SHA256 of
+// each JSON encoded entry using key, appending the hex encoded MAC as the `_sig` field
+// before writing the entry to underlying. Use VerifyLogEntry to detect tampering.
+func NewSignedWriteSyncer(underlying zapcore.WriteSyncer, key []byte) zapcore.WriteSyncer {
+	return &signedWriteSyncer{
+		underlying: underlying,
+		hashers: sync.Pool{
+			New: func() interface{} {
+				return hmac.New(sha256.New, key)
+			},
+		},
+	}
+}
+
+func (ws *signedWriteSyncer) Write(p []byte) (int, error) {
+	entry := bytes.TrimRight(p, "\n")
+	if len(entry) < 2 || entry[0] != '{' || entry[len(entry)-1] != '}' {
+		return 0, errNotJSONObject
+	}
+
+	mac := ws.sign(entry)
+
+	signed := make([]byte, 0, len(entry)+len(signatureKey)+hex.EncodedLen(len(mac))+4)
+	signed = append(signed, entry[:len(entry)-1]...)
+
+	if len(entry) > 2 {
+		signed = append(signed, ',')
+	}
+
+	signed = append(signed, signatureKey...)
+	signed = append(signed, hex.EncodeToString(mac)...)
+	signed = append(signed, '"', '}', '\n')
+
+	if _, err := ws.underlying.Write(signed); err != nil {
+		return 0, fmt.Errorf("failed to write signed entry: %w", err)
+	}
+
+	return len(p), nil
+}
+
+func (ws *signedWriteSyncer) Sync() error {
+	return ws.underlying.Sync()
+}
+
+func (ws *signedWriteSyncer) sign(entry []byte) []byte {
+	h := ws.hashers.Get().(hash.Hash)
+	defer ws.hashers.Put(h)
+
+	h.Reset()
+	h.Write(entry)
+
+	return h.Sum(nil)
+}
+
+// VerifyLogEntry verifies the `_sig` field of an entry written by the signed
+// WriteSyncer, reporting whether the signature matches the entry. An error is
+// returned when the entry has no signature.
+func VerifyLogEntry(entry []byte, key []byte) (bool, error) {
+	entry = bytes.TrimRight(entry, "\n")
+	if len(entry) < 2 || entry[0] != '{' || entry[len(entry)-1] != '}' {
+		return false, errNotJSONObject
+	}
+
+	idx := bytes.LastIndex(entry, []byte(signatureKey))
+	if idx < 0 || len(entry) < idx+len(signatureKey)+2 {
+		return false, errors.New("entry is not signed")
+	}
+
+	sig, err := hex.DecodeString(string(entry[idx+len(signatureKey) : len(entry)-2]))
+	if err != nil {
+		return false, fmt.Errorf("failed to decode signature: %w", err)
+	}
+
+	original := make([]byte, 0, idx+1)
+	original = append(original, bytes.TrimSuffix(entry[:idx], []byte(","))...)
+	original = append(original, '}')
+
+	h := hmac.New(sha256.New, key)
+	h.Write(original)
+
+	return hmac.Equal(sig, h.Sum(nil)), nil
+}
@@ -0,0 +1,41 @@
+package tracelog
+
+import (
+	"os"
+	"os/signal"
+	"sync"
+)
+
+// FlushOnSignal syncs the logger whenever one of the provided signals is received, so
+// buffered entries are not lost when the process is asked to stop. The signal is not
+// raised again, as handlers registered using `signal.Notify`, such as one started by
+// `signal.NotifyContext`, also receive it and decide how the process stops. Without
+// such a handler, the signals no longer terminate the process until the returned
+// function is called, which deregisters the handler without syncing.
+func (tl *TraceLogger) FlushOnSignal(sigs ...os.Signal) (stop func()) {
+	ch := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(ch, sigs...)
+
+	go func() {
+		for {
+			select {
+			case <-ch:
+				if err := tl.Sync(); err != nil {
+					tl.reportError(err)
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+
+	return func() {
+		once.Do(func() {
+			signal.Stop(ch)
+			close(done)
+		})
+	}
+}
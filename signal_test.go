@@ -0,0 +1,47 @@
+//go:build !windows && !plan9
+
+package tracelog
+
+import (
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// countingSyncer counts the calls to Sync.
+type countingSyncer struct {
+	syncBuffer
+	syncs atomic.Int32
+}
+
+func (s *countingSyncer) Sync() error {
+	s.syncs.Add(1)
+
+	return nil
+}
+
+func TestFlushOnSignalSyncsWithoutRaisingAgain(t *testing.T) {
+	out := &countingSyncer{}
+	tl := NewLogger(func(tl *TraceLogger) {
+		tl.output = out
+	})
+
+	stop := tl.FlushOnSignal(syscall.SIGUSR1)
+	defer stop()
+
+	for want := int32(1); want <= 2; want++ {
+		if err := syscall.Kill(syscall.Getpid(), syscall.SIGUSR1); err != nil {
+			t.Fatalf("failed to raise signal: %v", err)
+		}
+
+		deadline := time.Now().Add(5 * time.Second)
+		for out.syncs.Load() < want && time.Now().Before(deadline) {
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		if got := out.syncs.Load(); got < want {
+			t.Fatalf("synced %d times, want at least %d", got, want)
+		}
+	}
+}
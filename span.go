@@ -0,0 +1,187 @@
+package tracelog
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const instrumentationName = "github.com/ninnemana/tracelog"
+
+// An InstrumentedSpan wraps a `trace.Span`, logging a completion entry with the span's
+// duration and status when the span ends.
+type InstrumentedSpan struct {
+	trace.Span
+
+	logger *TraceLogger
+	name   string
+	start  time.Time
+
+	mu          sync.Mutex
+	code        codes.Code
+	description string
+	endOnce     sync.Once
+
+	// logCount counts the entries written within the span, when countLogs is set
+	// using WithSpanLogCount.
+	countLogs bool
+	logCount  atomic.Int64
+}
+
+// WithSpanLogCount counts the entries written within each span started using StartSpan,
+// setting the count as the `log.count` attribute when the span ends, to identify overly
+// verbose operations.
+func WithSpanLogCount() LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl != nil {
+			tl.countSpanLogs = true
+		}
+	}
+}
+
+// WithSpanStartOptions sets the options used when the TraceLogger starts spans, such as
+// in StartSpan and Middleware. The span kind defaults to server for inbound requests and
+// internal otherwise, and can be overridden using `trace.WithSpanKind`.
+func WithSpanStartOptions(opts ...trace.SpanStartOption) LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl != nil {
+			tl.spanStartOptions = append(tl.spanStartOptions, opts...)
+		}
+	}
+}
+
+// startOptions returns the options used to start a span of the provided default kind,
+// followed by the configured options and then opts.
+func (tl *TraceLogger) startOptions(kind trace.SpanKind, opts ...trace.SpanStartOption) []trace.SpanStartOption {
+	options := make([]trace.SpanStartOption, 0, 1+len(tl.spanStartOptions)+len(opts))
+	options = append(options, trace.WithSpanKind(kind))
+	options = append(options, tl.spanStartOptions...)
+
+	return append(options, opts...)
+}
+
+// tracer returns the tracer used to start spans.
+func (tl *TraceLogger) tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
+
+// StartSpan starts a child span of the span in the logger's `context.Context`, returning
+// a logger associated with the child span along with the span. The returned logger's
+// context contains the InstrumentedSpan as its current span, and its entries include the
+// `parentSpanID` field when the span has a parent.
+func (tl *TraceLogger) StartSpan(name string, opts ...trace.SpanStartOption) (*TraceLogger, *InstrumentedSpan) {
+	parent := trace.SpanContextFromContext(tl.Context())
+	ctx, span := tl.tracer().Start(tl.Context(), name, tl.startOptions(trace.SpanKindInternal, opts...)...)
+
+	is := &InstrumentedSpan{
+		Span:      span,
+		name:      name,
+		start:     time.Now(),
+		countLogs: tl.countSpanLogs,
+	}
+
+	// Rebind replaces the parent's correlation fields rather than adding another set.
+	l := tl.Rebind(trace.ContextWithSpan(ctx, is))
+
+	// The span is only a child when it continues the parent's trace, as options such
+	// as `trace.WithNewRoot` start a new trace, and no-op tracers reuse the parent.
+	if sc := span.SpanContext(); parent.IsValid() && sc.TraceID() == parent.TraceID() && sc.SpanID() != parent.SpanID() {
+		l.bindSpan(zap.String("parentSpanID", parent.SpanID().String()))
+	}
+
+	is.logger = l.WithCallerSkip(1)
+
+	return l, is
+}
+
+// WithTimeout returns a logger whose `context.Context` times out after d, along with a
+// child span carrying a `timeout` attribute. The returned cancel function cancels the
+// context and ends the span, and is safe to call multiple times, such as with `defer`.
+func (tl *TraceLogger) WithTimeout(d time.Duration) (*TraceLogger, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(tl.Context(), d)
+	ctx, span := tl.tracer().Start(
+		ctx,
+		"timeout",
+		tl.startOptions(
+			trace.SpanKindInternal,
+			trace.WithAttributes(attribute.String("timeout", d.String())),
+		)...,
+	)
+
+	var once sync.Once
+
+	return tl.Rebind(ctx), func() {
+		once.Do(func() {
+			cancel()
+			span.End()
+		})
+	}
+}
+
+// SetStatus records the status for the completion entry and sets it on the span.
+func (s *InstrumentedSpan) SetStatus(code codes.Code, description string) {
+	s.mu.Lock()
+	s.code = code
+	s.description = description
+	s.mu.Unlock()
+
+	s.Span.SetStatus(code, description)
+}
+
+// End logs a completion entry with the span's duration and status, then ends the span.
+// Only the first call has any effect, so it is safe to call multiple times.
+func (s *InstrumentedSpan) End(opts ...trace.SpanEndOption) {
+	var first bool
+	s.endOnce.Do(func() {
+		first = true
+	})
+
+	if !first {
+		return
+	}
+
+	s.mu.Lock()
+	code, description := s.code, s.description
+	s.mu.Unlock()
+
+	args := []interface{}{
+		zap.String("span", s.name),
+		zap.Duration("duration", time.Since(s.start)),
+	}
+
+	if code != codes.Unset {
+		args = append(args, zap.String("status", code.String()))
+
+		if description != "" {
+			args = append(args, zap.String("statusDescription", description))
+		}
+	}
+
+	if s.countLogs {
+		s.Span.SetAttributes(attribute.Int64("log.count", s.logCount.Load()))
+	}
+
+	s.logger.Info("span completed", args...)
+	s.Span.End(opts...)
+}
+
+var _ trace.Span = (*InstrumentedSpan)(nil)
+
+// Event records an event with the provided attributes on the span in the logger's
+// `context.Context`, timestamped now.
+func (tl *TraceLogger) Event(name string, attrs ...attribute.KeyValue) {
+	tl.EventAt(name, time.Now(), attrs...)
+}
+
+// EventAt records an event with the provided attributes on the span in the logger's
+// `context.Context`, timestamped t, such as when backfilling events.
+func (tl *TraceLogger) EventAt(name string, t time.Time, attrs ...attribute.KeyValue) {
+	trace.SpanFromContext(tl.Context()).AddEvent(name, trace.WithAttributes(attrs...), trace.WithTimestamp(t))
+}
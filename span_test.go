@@ -0,0 +1,153 @@
+package tracelog
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestStartSpanReplacesCorrelationFields(t *testing.T) {
+	useSpanRecorder(t)
+
+	tl, buf := newBufferedLogger()
+	parent, parentSpan := tl.SetContext(contextWithSpan(1, 1)).StartSpan("parent")
+	defer parentSpan.End()
+
+	child, span := parent.StartSpan("child")
+	child.Info("in child")
+	span.End()
+
+	lines := buf.Lines()
+	if len(lines) != 2 {
+		t.Fatalf("got %d entries, want 2", len(lines))
+	}
+
+	for _, line := range lines {
+		assertUniqueKeys(t, line, append(correlationKeys, "parentSpanID")...)
+	}
+
+	entries := buf.Entries(t)
+	want := span.SpanContext()
+	for _, entry := range entries {
+		if entry["traceID"] != want.TraceID().String() || entry["spanID"] != want.SpanID().String() {
+			t.Errorf("entry %v is not correlated with the child span %s", entry, want.SpanID())
+		}
+
+		if entry["parentSpanID"] != parentSpan.SpanContext().SpanID().String() {
+			t.Errorf("entry %v does not reference the parent span %s", entry, parentSpan.SpanContext().SpanID())
+		}
+	}
+}
+
+func TestWithTimeoutReplacesCorrelationFields(t *testing.T) {
+	rec := useSpanRecorder(t)
+
+	tl, buf := newBufferedLogger()
+	lg, cancel := tl.SetContext(contextWithSpan(1, 1)).WithTimeout(time.Minute)
+	lg.Info("with timeout")
+	cancel()
+
+	ended := rec.Ended()
+	if len(ended) != 1 {
+		t.Fatalf("got %d ended spans, want 1", len(ended))
+	}
+
+	line := buf.Lines()[0]
+	assertUniqueKeys(t, line, correlationKeys...)
+
+	if entry := buf.Entries(t)[0]; entry["spanID"] != ended[0].SpanContext().SpanID().String() {
+		t.Errorf("entry %v is not correlated with the timeout span", entry)
+	}
+}
+
+func TestWithSpanStartOptionsKind(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  []LoggerOption
+		start func(tl *TraceLogger)
+		want  trace.SpanKind
+	}{
+		{
+			name:  "StartSpan default",
+			start: func(tl *TraceLogger) { _, span := tl.StartSpan("op"); span.End() },
+			want:  trace.SpanKindInternal,
+		},
+		{
+			name:  "StartSpan override",
+			opts:  []LoggerOption{WithSpanStartOptions(trace.WithSpanKind(trace.SpanKindProducer))},
+			start: func(tl *TraceLogger) { _, span := tl.StartSpan("op"); span.End() },
+			want:  trace.SpanKindProducer,
+		},
+		{
+			name:  "Middleware default",
+			start: serveMiddlewareRequest,
+			want:  trace.SpanKindServer,
+		},
+		{
+			name:  "Middleware override",
+			opts:  []LoggerOption{WithSpanStartOptions(trace.WithSpanKind(trace.SpanKindConsumer))},
+			start: serveMiddlewareRequest,
+			want:  trace.SpanKindConsumer,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			rec := useSpanRecorder(t)
+			tl, _ := newBufferedLogger(tt.opts...)
+
+			tt.start(tl)
+
+			spans := rec.Ended()
+			if len(spans) != 1 {
+				t.Fatalf("got %d spans, want 1", len(spans))
+			}
+
+			if got := spans[0].SpanKind(); got != tt.want {
+				t.Errorf("span kind = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// serveMiddlewareRequest serves a request using the Middleware of tl.
+func serveMiddlewareRequest(tl *TraceLogger) {
+	h := Middleware(tl)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+}
+
+func TestInstrumentedSpanEndIsIdempotent(t *testing.T) {
+	rec := useSpanRecorder(t)
+	tl, buf := newBufferedLogger(WithSpanLogCount())
+
+	child, span := tl.StartSpan("op")
+	child.Info("in span")
+
+	span.End()
+	span.End()
+
+	if entries := buf.Entries(t); len(entries) != 2 {
+		t.Errorf("got %d entries, want the entry and a single completion entry", len(entries))
+	}
+
+	ended := rec.Ended()
+	if len(ended) != 1 {
+		t.Fatalf("got %d ended spans, want 1", len(ended))
+	}
+
+	var counts int
+	for _, attr := range ended[0].Attributes() {
+		if attr.Key == "log.count" {
+			counts++
+		}
+	}
+
+	if counts != 1 {
+		t.Errorf("log.count set %d times, want once", counts)
+	}
+}
@@ -0,0 +1,72 @@
+package tracelog
+
+import (
+	"context"
+	"sync"
+
+	"go.uber.org/zap"
+)
+
+type spanLogContextKey struct{}
+
+// A SpanLogContext accumulates fields across the call frames handling a single span,
+// similar to a mapped diagnostic context. It is safe for concurrent use.
+type SpanLogContext struct {
+	mu     sync.Mutex
+	keys   []string
+	values map[string]interface{}
+}
+
+// NewSpanContext returns a copy of ctx carrying an empty SpanLogContext. Create it
+// alongside the span, so the fields are scoped to the span's lifetime.
+func NewSpanContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, spanLogContextKey{}, &SpanLogContext{
+		values: make(map[string]interface{}),
+	})
+}
+
+// SpanContextFrom returns the SpanLogContext carried by ctx, or nil when there is none.
+func SpanContextFrom(ctx context.Context) *SpanLogContext {
+	if ctx == nil {
+		return nil
+	}
+
+	slc, _ := ctx.Value(spanLogContextKey{}).(*SpanLogContext)
+
+	return slc
+}
+
+// Set sets the value of the field with the provided key, replacing any previous value.
+// It does nothing when called on a nil SpanLogContext.
+func (c *SpanLogContext) Set(key string, val interface{}) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.values[key]; !ok {
+		c.keys = append(c.keys, key)
+	}
+
+	c.values[key] = val
+}
+
+// Fields returns the fields set, in the order they were first set, encoded using
+// `zap.Any`.
+func (c *SpanLogContext) Fields() []zap.Field {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fields := make([]zap.Field, 0, len(c.keys))
+	for _, key := range c.keys {
+		fields = append(fields, zap.Any(key, c.values[key]))
+	}
+
+	return fields
+}
@@ -0,0 +1,142 @@
+package tracelog
+
+import (
+	"errors"
+	"sort"
+
+	"go.uber.org/multierr"
+	"go.uber.org/zap/zapcore"
+)
+
+// levelSplitWriteSyncer dispatches JSON encoded entries based on their level.
+type levelSplitWriteSyncer struct {
+	// levels is sorted in ascending order.
+	levels  []zapcore.Level
+	syncers map[zapcore.Level]zapcore.WriteSyncer
+}
+
+// LevelSplitWriteSyncer creates a `zapcore.WriteSyncer` that dispatches each JSON encoded
+// entry to a WriteSyncer based on the value of its "level" key. Entries are written to
+// the WriteSyncer of the highest configured level at or below the entry's level, so
+// `{InfoLevel: stdout, WarnLevel: stderr}` writes ErrorLevel entries to stderr. Entries
+// below every configured level, or without a level, use the lowest configured level.
+func LevelSplitWriteSyncer(levels map[zapcore.Level]zapcore.WriteSyncer) zapcore.WriteSyncer {
+	ws := &levelSplitWriteSyncer{
+		syncers: make(map[zapcore.Level]zapcore.WriteSyncer, len(levels)),
+	}
+
+	for lvl, syncer := range levels {
+		if syncer == nil {
+			continue
+		}
+
+		ws.levels = append(ws.levels, lvl)
+		ws.syncers[lvl] = syncer
+	}
+
+	sort.Slice(ws.levels, func(i, j int) bool {
+		return ws.levels[i] < ws.levels[j]
+	})
+
+	return ws
+}
+
+// WithLevelSplitOutput writes Debug and Info entries to stdout, and Warn entries and
+// above to stderr. This is commonly used where stdout and stderr are collected separately.
+// Entries are dispatched by a core enabled for the levels of each output, so any encoder
+// may be used.
+func WithLevelSplitOutput(stdout, stderr zapcore.WriteSyncer) LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl != nil {
+			tl.output = LevelSplitWriteSyncer(map[zapcore.Level]zapcore.WriteSyncer{
+				zapcore.DebugLevel: stdout,
+				zapcore.WarnLevel:  stderr,
+			})
+		}
+	}
+}
+
+func (ws *levelSplitWriteSyncer) Write(p []byte) (int, error) {
+	if len(ws.levels) == 0 {
+		return 0, errors.New("no level split outputs configured")
+	}
+
+	target := ws.levels[0]
+	if lvl, ok := entryLevel(p); ok {
+		for _, l := range ws.levels {
+			if l > lvl {
+				break
+			}
+
+			target = l
+		}
+	}
+
+	return ws.syncers[target].Write(p)
+}
+
+func (ws *levelSplitWriteSyncer) Sync() error {
+	var err error
+	for _, lvl := range ws.levels {
+		err = multierr.Append(err, ws.syncers[lvl].Sync())
+	}
+
+	return err
+}
+
+// mapSyncers returns a copy of ws with each WriteSyncer replaced by the result of f.
+func (ws *levelSplitWriteSyncer) mapSyncers(f func(zapcore.WriteSyncer) zapcore.WriteSyncer) *levelSplitWriteSyncer {
+	mapped := &levelSplitWriteSyncer{
+		levels:  ws.levels,
+		syncers: make(map[zapcore.Level]zapcore.WriteSyncer, len(ws.syncers)),
+	}
+
+	for lvl, syncer := range ws.syncers {
+		mapped.syncers[lvl] = f(syncer)
+	}
+
+	return mapped
+}
+
+// newCore creates a core per WriteSyncer using newCore, each enabled for the levels
+// dispatched to its WriteSyncer, so the level is not decoded from each entry.
+func (ws *levelSplitWriteSyncer) newCore(newCore func(zapcore.WriteSyncer) zapcore.Core) zapcore.Core {
+	cores := make([]zapcore.Core, 0, len(ws.levels))
+
+	for i, lvl := range ws.levels {
+		i := i
+		cores = append(cores, &levelRangeCore{
+			Core: newCore(ws.syncers[lvl]),
+			enabled: func(l zapcore.Level) bool {
+				return (i == 0 || l >= ws.levels[i]) && (i == len(ws.levels)-1 || l < ws.levels[i+1])
+			},
+		})
+	}
+
+	return zapcore.NewTee(cores...)
+}
+
+// levelRangeCore writes the entries of the wrapped core within a range of levels.
+type levelRangeCore struct {
+	zapcore.Core
+	enabled func(zapcore.Level) bool
+}
+
+func (c *levelRangeCore) Enabled(lvl zapcore.Level) bool {
+	return c.enabled(lvl) && c.Core.Enabled(lvl)
+}
+
+func (c *levelRangeCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelRangeCore{
+		Core:    c.Core.With(fields),
+		enabled: c.enabled,
+	}
+}
+
+func (c *levelRangeCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.enabled(ent.Level) {
+		return ce
+	}
+
+	return c.Core.Check(ent, ce)
+}
@@ -0,0 +1,76 @@
+package tracelog
+
+import (
+	"strings"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+)
+
+func TestWithLevelSplitOutputDispatchesByLevel(t *testing.T) {
+	stdout, stderr := &syncBuffer{}, &syncBuffer{}
+	tl := NewLogger(WithLevelSplitOutput(stdout, stderr), WithLevelEnabler(zapcore.DebugLevel))
+
+	tl.Debug("debug")
+	tl.Info("info")
+	tl.Warn("warn")
+	tl.Error("error")
+
+	if got := messages(t, stdout); strings.Join(got, ",") != "debug,info" {
+		t.Errorf("stdout entries = %v, want debug and info", got)
+	}
+
+	if got := messages(t, stderr); strings.Join(got, ",") != "warn,error" {
+		t.Errorf("stderr entries = %v, want warn and error", got)
+	}
+}
+
+func TestWithLevelSplitOutputSupportsNonJSONEncoders(t *testing.T) {
+	stdout, stderr := &syncBuffer{}, &syncBuffer{}
+	tl := NewLogger(WithLevelSplitOutput(stdout, stderr), WithLogfmtEncoder())
+
+	tl.Info("info")
+	tl.Error("error")
+
+	if n := len(stdout.Lines()); n != 1 {
+		t.Errorf("got %d stdout entries, want 1", n)
+	}
+
+	if lines := stderr.Lines(); len(lines) != 1 || !strings.Contains(lines[0], "msg=error") {
+		t.Errorf("stderr entries = %q, want the logfmt error entry", lines)
+	}
+}
+
+func TestLevelSplitWriteSyncerUsesLowestLevelBelowRange(t *testing.T) {
+	info, warn := &syncBuffer{}, &syncBuffer{}
+	tl := NewLogger(WithLevelEnabler(zapcore.DebugLevel), func(tl *TraceLogger) {
+		tl.output = LevelSplitWriteSyncer(map[zapcore.Level]zapcore.WriteSyncer{
+			zapcore.InfoLevel: info,
+			zapcore.WarnLevel: warn,
+		})
+	})
+
+	tl.Debug("debug")
+	tl.DPanic("dpanic")
+
+	if got := messages(t, info); strings.Join(got, ",") != "debug" {
+		t.Errorf("info entries = %v, want debug", got)
+	}
+
+	if got := messages(t, warn); strings.Join(got, ",") != "dpanic" {
+		t.Errorf("warn entries = %v, want dpanic", got)
+	}
+}
+
+// messages returns the messages of the JSON entries written to buf.
+func messages(t *testing.T, buf *syncBuffer) []string {
+	t.Helper()
+
+	var msgs []string
+	for _, e := range buf.Entries(t) {
+		msg, _ := e["msg"].(string)
+		msgs = append(msgs, msg)
+	}
+
+	return msgs
+}
@@ -0,0 +1,161 @@
+package tracelog
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"sync"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// updateGoldenEnv is the environment variable which, when set to "true", causes
+// AssertLogsMatchFile to write the golden file instead of comparing against it.
+const updateGoldenEnv = "UPDATE_GOLDEN"
+
+// A LogSpy captures the entries written by a TraceLogger, for inspection in tests.
+type LogSpy struct {
+	mu      sync.Mutex
+	entries []map[string]interface{}
+}
+
+// NewLogSpy creates an empty LogSpy. Use WithLogSpy to capture entries.
+func NewLogSpy() *LogSpy {
+	return &LogSpy{}
+}
+
+// WithLogSpy captures the entries written by the base logger in the provided LogSpy,
+// in addition to writing them to the configured output.
+func WithLogSpy(spy *LogSpy) LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl == nil || spy == nil {
+			return
+		}
+
+		tl.zapOpts = append(tl.zapOpts, zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return zapcore.NewTee(core, &spyCore{
+				LevelEnabler: core,
+				spy:          spy,
+			})
+		}))
+	}
+}
+
+// Entries returns the captured entries. Each entry contains the "level", "msg" and
+// "fields" keys, and the "logger" key when the logger is named. Timestamps and callers
+// are not captured so the entries are stable across runs.
+func (s *LogSpy) Entries() []map[string]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := make([]map[string]interface{}, len(s.entries))
+	copy(entries, s.entries)
+
+	return entries
+}
+
+// AsJSON returns the captured entries as indented JSON with sorted keys.
+func (s *LogSpy) AsJSON() []byte {
+	b, err := json.MarshalIndent(s.Entries(), "", "  ")
+	if err != nil {
+		return []byte(err.Error())
+	}
+
+	return append(b, '\n')
+}
+
+// Reset discards the captured entries.
+func (s *LogSpy) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries = nil
+}
+
+func (s *LogSpy) add(entry map[string]interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.entries = append(s.entries, entry)
+}
+
+// AssertLogsMatchFile compares the entries captured by spy, encoded using AsJSON, with
+// the contents of the golden file, failing the test on mismatch. When the UPDATE_GOLDEN
+// environment variable is "true" the golden file is written instead.
+func AssertLogsMatchFile(t testing.TB, spy *LogSpy, goldenPath string) {
+	t.Helper()
+
+	got := spy.AsJSON()
+
+	if os.Getenv(updateGoldenEnv) == "true" {
+		if err := os.WriteFile(goldenPath, got, 0o644); err != nil {
+			t.Fatalf("failed to write golden file %s: %v", goldenPath, err)
+		}
+
+		return
+	}
+
+	want, err := os.ReadFile(goldenPath)
+	if err != nil {
+		t.Fatalf("failed to read golden file %s: %v", goldenPath, err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Fatalf("log entries do not match golden file %s\ngot:\n%s\nwant:\n%s", goldenPath, got, want)
+	}
+}
+
+// spyCore records entries in a LogSpy.
+type spyCore struct {
+	zapcore.LevelEnabler
+	fields []zapcore.Field
+	spy    *LogSpy
+}
+
+func (c *spyCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.fields = make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	clone.fields = append(clone.fields, c.fields...)
+	clone.fields = append(clone.fields, fields...)
+
+	return &clone
+}
+
+func (c *spyCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+
+	return ce
+}
+
+func (c *spyCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range c.fields {
+		f.AddTo(enc)
+	}
+
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+
+	entry := map[string]interface{}{
+		"level":  ent.Level.String(),
+		"msg":    ent.Message,
+		"fields": enc.Fields,
+	}
+
+	if ent.LoggerName != "" {
+		entry["logger"] = ent.LoggerName
+	}
+
+	c.spy.add(entry)
+
+	return nil
+}
+
+func (c *spyCore) Sync() error {
+	return nil
+}
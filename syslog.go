@@ -0,0 +1,230 @@
+//go:build !windows && !plan9
+
+package tracelog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log/syslog"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	syslogFacilityMask = 0xf8
+	syslogNilValue     = "-"
+)
+
+// syslogWriteSyncer writes entries to a syslog server using the RFC-5424 format.
+type syslogWriteSyncer struct {
+	network  string
+	addr     string
+	facility syslog.Priority
+	severity syslog.Priority
+	tag      string
+	hostname string
+
+	mu     sync.Mutex
+	conn   net.Conn
+	closed bool
+}
+
+// NewSyslogWriteSyncer creates a `zapcore.WriteSyncer` that writes entries to the
+// syslog server at addr, prefixing each entry with an RFC-5424 header. The facility
+// is taken from priority, while the severity is mapped from the level of each entry,
+// falling back to the severity of priority. An empty network connects to the local
+// syslog server.
+func NewSyslogWriteSyncer(network, addr string, priority syslog.Priority, tag string) (zapcore.WriteSyncer, error) {
+	return newSyslogWriteSyncer(network, addr, priority, tag)
+}
+
+func newSyslogWriteSyncer(network, addr string, priority syslog.Priority, tag string) (*syslogWriteSyncer, error) {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = syslogNilValue
+	}
+
+	if tag == "" {
+		tag = defaultServiceName()
+	}
+
+	ws := &syslogWriteSyncer{
+		network:  network,
+		addr:     addr,
+		facility: priority & syslogFacilityMask,
+		severity: priority &^ syslogFacilityMask,
+		tag:      tag,
+		hostname: hostname,
+	}
+
+	if err := ws.connect(); err != nil {
+		return nil, err
+	}
+
+	return ws, nil
+}
+
+// WithSyslogOutput writes entries to the syslog server at addr over UDP instead of stdout.
+// The facility is taken from priority, and the severity is mapped from the level of
+// each entry.
+func WithSyslogOutput(addr string, priority syslog.Priority) LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl == nil {
+			return
+		}
+
+		ws, err := newSyslogWriteSyncer("udp", addr, priority, "")
+		if err != nil {
+			tl.reportError(fmt.Errorf("failed to create syslog output: %w", err))
+
+			return
+		}
+
+		// Entries are written by a core per level, each using the severity of its level.
+		levels := make(map[zapcore.Level]zapcore.WriteSyncer)
+		for lvl := zapcore.DebugLevel; lvl <= zapcore.FatalLevel; lvl++ {
+			levels[lvl] = &syslogSeverityWriteSyncer{
+				syslogWriteSyncer: ws,
+				severity:          syslogSeverity(lvl),
+			}
+		}
+
+		tl.output = LevelSplitWriteSyncer(levels)
+		tl.addCloser(ws)
+	}
+}
+
+// syslogSeverityWriteSyncer writes entries to a syslog server using a fixed severity.
+type syslogSeverityWriteSyncer struct {
+	*syslogWriteSyncer
+	severity syslog.Priority
+}
+
+func (w *syslogSeverityWriteSyncer) Write(p []byte) (int, error) {
+	return w.write(p, w.severity)
+}
+
+func (w *syslogWriteSyncer) connect() error {
+	if w.network != "" {
+		conn, err := net.Dial(w.network, w.addr)
+		if err != nil {
+			return fmt.Errorf("failed to connect to syslog server: %w", err)
+		}
+
+		w.conn = conn
+
+		return nil
+	}
+
+	for _, path := range []string{"/dev/log", "/var/run/syslog", "/var/run/log"} {
+		for _, network := range []string{"unixgram", "unix"} {
+			if conn, err := net.Dial(network, path); err == nil {
+				w.conn = conn
+
+				return nil
+			}
+		}
+	}
+
+	return errors.New("failed to connect to local syslog server")
+}
+
+func (w *syslogWriteSyncer) Write(p []byte) (int, error) {
+	severity := w.severity
+	if lvl, ok := entryLevel(p); ok {
+		severity = syslogSeverity(lvl)
+	}
+
+	return w.write(p, severity)
+}
+
+// write writes p to the syslog server using the provided severity.
+func (w *syslogWriteSyncer) write(p []byte, severity syslog.Priority) (int, error) {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(
+		&buf,
+		"<%d>1 %s %s %s %d %s %s ",
+		w.facility|severity,
+		time.Now().Format(time.RFC3339Nano),
+		w.hostname,
+		w.tag,
+		os.Getpid(),
+		syslogNilValue,
+		syslogNilValue,
+	)
+	buf.Write(p)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.closed {
+		return 0, errors.New("syslog write syncer is closed")
+	}
+
+	if w.conn == nil {
+		if err := w.connect(); err != nil {
+			return 0, err
+		}
+	}
+
+	if _, err := w.conn.Write(buf.Bytes()); err != nil {
+		// Reconnect on the next write, as stream connections may have been closed
+		// by the server.
+		w.conn.Close()
+		w.conn = nil
+
+		return 0, fmt.Errorf("failed to write to syslog server: %w", err)
+	}
+
+	return len(p), nil
+}
+
+// Sync is a no-op, as entries are not buffered.
+func (w *syslogWriteSyncer) Sync() error {
+	return nil
+}
+
+// Close closes the connection to the syslog server. Writes fail once closed.
+func (w *syslogWriteSyncer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.closed = true
+
+	if w.conn == nil {
+		return nil
+	}
+
+	err := w.conn.Close()
+	w.conn = nil
+
+	return err
+}
+
+// syslogSeverity maps a zap level to the corresponding syslog severity.
+func syslogSeverity(lvl zapcore.Level) syslog.Priority {
+	switch lvl {
+	case zapcore.DebugLevel:
+		return syslog.LOG_DEBUG
+	case zapcore.InfoLevel:
+		return syslog.LOG_INFO
+	case zapcore.WarnLevel:
+		return syslog.LOG_WARNING
+	case zapcore.ErrorLevel:
+		return syslog.LOG_ERR
+	case zapcore.DPanicLevel:
+		return syslog.LOG_CRIT
+	case zapcore.PanicLevel:
+		return syslog.LOG_ALERT
+	case zapcore.FatalLevel:
+		return syslog.LOG_EMERG
+	default:
+		return syslog.LOG_NOTICE
+	}
+}
@@ -0,0 +1,63 @@
+//go:build !windows && !plan9
+
+package tracelog
+
+import (
+	"log/syslog"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithSyslogOutputMapsLevelToSeverity(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer conn.Close()
+
+	tl := NewLogger(WithSyslogOutput(conn.LocalAddr().String(), syslog.LOG_LOCAL0|syslog.LOG_INFO), WithLogfmtEncoder())
+
+	tl.Info("started")
+	tl.Error("failed")
+
+	for _, want := range []string{"<134>1 ", "<131>1 "} {
+		_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+		buf := make([]byte, 4096)
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatalf("failed to read syslog message: %v", err)
+		}
+
+		if msg := string(buf[:n]); !strings.HasPrefix(msg, want) {
+			t.Errorf("message %q, want prefix %q", msg, want)
+		}
+	}
+}
+
+func TestSyslogWriteSyncerRejectsWritesOnceClosed(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer conn.Close()
+
+	ws, err := newSyslogWriteSyncer("udp", conn.LocalAddr().String(), syslog.LOG_LOCAL0|syslog.LOG_INFO, "")
+	if err != nil {
+		t.Fatalf("failed to create syslog output: %v", err)
+	}
+
+	if err := ws.Close(); err != nil {
+		t.Fatalf("failed to close: %v", err)
+	}
+
+	if _, err := ws.Write([]byte("late")); err == nil {
+		t.Error("expected an error writing once closed")
+	}
+
+	if ws.conn != nil {
+		t.Error("write reconnected once closed")
+	}
+}
@@ -0,0 +1,299 @@
+package tracelog
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// NewTestingLogger creates a TraceLogger that writes its entries to the provided test
+// using `t.Log`, so they are only shown for failing tests or when running with `-v`.
+// Entries at ErrorLevel and above are written using `t.Error`, failing the test, and
+// Fatal entries stop the test using WriteThenGoexit rather than exiting. When the test
+// fails, the entries written within each span are also logged grouped by span, along
+// with the events recorded on the span when the global tracer provider is an SDK
+// `sdktrace.TracerProvider` installed before calling NewTestingLogger.
+func NewTestingLogger(t testing.TB) *TraceLogger {
+	t.Helper()
+
+	rec := recordSpans(t)
+	entries := &spanEntries{}
+	t.Cleanup(func() {
+		if t.Failed() {
+			entries.dump(t, rec)
+		}
+	})
+
+	return NewLogger(
+		WithLevelEnabler(zapcore.DebugLevel),
+		WithFatalHook(WriteThenGoexit),
+		func(tl *TraceLogger) {
+			tl.output = &testingWriteSyncer{t: t}
+		},
+		func(tl *TraceLogger) {
+			tl.contextCores = true
+			tl.zapOpts = append(tl.zapOpts, zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+				return zapcore.NewTee(core, &spanEntryCore{
+					LevelEnabler: core,
+					enc:          zapcore.NewJSONEncoder(zap.NewDevelopmentEncoderConfig()),
+					entries:      entries,
+				})
+			}))
+		},
+	)
+}
+
+// recordSpans records the spans started until the test ends when the global tracer
+// provider is an SDK provider, returning nil otherwise.
+func recordSpans(t testing.TB) *tracetest.SpanRecorder {
+	tp, ok := otel.GetTracerProvider().(*sdktrace.TracerProvider)
+	if !ok {
+		return nil
+	}
+
+	rec := tracetest.NewSpanRecorder()
+	tp.RegisterSpanProcessor(rec)
+	t.Cleanup(func() {
+		tp.UnregisterSpanProcessor(rec)
+	})
+
+	return rec
+}
+
+// testingWriteSyncer writes JSON encoded entries to a test, failing the test for
+// entries at ErrorLevel and above.
+type testingWriteSyncer struct {
+	t testing.TB
+}
+
+func (ws *testingWriteSyncer) Write(p []byte) (int, error) {
+	ws.t.Helper()
+
+	msg := strings.TrimSuffix(string(p), "\n")
+	if lvl, ok := entryLevel(p); ok && lvl >= zapcore.ErrorLevel {
+		ws.t.Error(msg)
+	} else {
+		ws.t.Log(msg)
+	}
+
+	return len(p), nil
+}
+
+func (ws *testingWriteSyncer) Sync() error {
+	return nil
+}
+
+// spanEntries records encoded entries by the span they were written within.
+type spanEntries struct {
+	mu      sync.Mutex
+	order   []trace.SpanContext
+	entries map[trace.SpanID][]string
+}
+
+func (e *spanEntries) add(sc trace.SpanContext, entry string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.entries == nil {
+		e.entries = make(map[trace.SpanID][]string)
+	}
+
+	if _, ok := e.entries[sc.SpanID()]; !ok {
+		e.order = append(e.order, sc)
+	}
+
+	e.entries[sc.SpanID()] = append(e.entries[sc.SpanID()], entry)
+}
+
+// dump logs the entries of each span, followed by the events recorded on the span by
+// rec, which may be nil.
+func (e *spanEntries) dump(t testing.TB, rec *tracetest.SpanRecorder) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	spans := make(map[trace.SpanID]sdktrace.ReadWriteSpan)
+	if rec != nil {
+		for _, span := range rec.Started() {
+			spans[span.SpanContext().SpanID()] = span
+		}
+	}
+
+	for _, sc := range e.order {
+		t.Logf(
+			"span %s (trace %s) entries:\n%s",
+			sc.SpanID(),
+			sc.TraceID(),
+			strings.Join(e.entries[sc.SpanID()], ""),
+		)
+
+		span, ok := spans[sc.SpanID()]
+		if !ok || len(span.Events()) == 0 {
+			continue
+		}
+
+		var events strings.Builder
+		for _, event := range span.Events() {
+			fmt.Fprintf(&events, "%s\t%s", event.Time.Format(time.RFC3339Nano), event.Name)
+
+			for _, attr := range event.Attributes {
+				fmt.Fprintf(&events, "\t%s=%s", attr.Key, attr.Value.Emit())
+			}
+
+			events.WriteByte('\n')
+		}
+
+		t.Logf("span %s (trace %s) events:\n%s", sc.SpanID(), sc.TraceID(), events.String())
+	}
+}
+
+// spanEntryCore records the entries written within a valid span.
+type spanEntryCore struct {
+	zapcore.LevelEnabler
+	enc     zapcore.Encoder
+	entries *spanEntries
+}
+
+func (c *spanEntryCore) With(fields []zapcore.Field) zapcore.Core {
+	enc := c.enc.Clone()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+
+	return &spanEntryCore{
+		LevelEnabler: c.LevelEnabler,
+		enc:          enc,
+		entries:      c.entries,
+	}
+}
+
+func (c *spanEntryCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+
+	return ce
+}
+
+func (c *spanEntryCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	ctx, ok := contextFromFields(fields)
+	if !ok {
+		return nil
+	}
+
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+
+	buf, err := c.enc.EncodeEntry(ent, fields)
+	if err != nil {
+		return err
+	}
+
+	c.entries.add(sc, buf.String())
+	buf.Free()
+
+	return nil
+}
+
+func (c *spanEntryCore) Sync() error {
+	return nil
+}
+
+// AssertPropagation verifies that the trace context of ctx survives a round trip through
+// WithRequest and FromRequest using tl's propagator, failing the test when the extracted
+// trace or span ID differs. A sampled span context with random IDs is used when ctx does
+// not contain a valid span.
+func AssertPropagation(t testing.TB, tl *TraceLogger, ctx context.Context) {
+	t.Helper()
+
+	want := trace.SpanContextFromContext(ctx)
+	if !want.IsValid() {
+		var (
+			traceID trace.TraceID
+			spanID  trace.SpanID
+		)
+
+		if _, err := rand.Read(traceID[:]); err != nil {
+			t.Fatalf("failed to generate trace ID: %v", err)
+		}
+
+		if _, err := rand.Read(spanID[:]); err != nil {
+			t.Fatalf("failed to generate span ID: %v", err)
+		}
+
+		want = trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    traceID,
+			SpanID:     spanID,
+			TraceFlags: trace.FlagsSampled,
+		})
+		ctx = trace.ContextWithSpanContext(ctx, want)
+	}
+
+	r := tl.WithRequest(ctx, httptest.NewRequest(http.MethodGet, "/", nil))
+	r = r.WithContext(context.Background())
+
+	got := trace.SpanContextFromContext(tl.FromRequest(r).Context())
+
+	if got.TraceID() != want.TraceID() {
+		t.Errorf("extracted trace ID %s, want %s (headers: %v)", got.TraceID(), want.TraceID(), r.Header)
+	}
+
+	if got.SpanID() != want.SpanID() {
+		t.Errorf("extracted span ID %s, want %s (headers: %v)", got.SpanID(), want.SpanID(), r.Header)
+	}
+}
+
+// AssertNoPropagation verifies that extracting the trace context of r using FromRequest
+// produces an invalid span context, as expected for requests without trace headers.
+func AssertNoPropagation(t testing.TB, tl *TraceLogger, r *http.Request) {
+	t.Helper()
+
+	if sc := trace.SpanContextFromContext(tl.FromRequest(r).Context()); sc.IsValid() {
+		t.Errorf("extracted trace ID %s and span ID %s, want no span context", sc.TraceID(), sc.SpanID())
+	}
+}
+
+// VerifyPropagation verifies that the propagator injects a known span context into a
+// carrier and extracts it back unchanged, failing the test and reporting each trace or
+// span ID which did not round-trip. Use it to validate a propagator configuration.
+func VerifyPropagation(t testing.TB, propagator propagation.TextMapPropagator) {
+	t.Helper()
+
+	if propagator == nil {
+		t.Fatal("no propagator provided")
+	}
+
+	want := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+		SpanID:     trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+		TraceFlags: trace.FlagsSampled,
+	})
+
+	carrier := propagation.HeaderCarrier(http.Header{})
+	propagator.Inject(trace.ContextWithSpanContext(context.Background(), want), carrier)
+
+	got := trace.SpanContextFromContext(propagator.Extract(context.Background(), carrier))
+
+	if got.TraceID() != want.TraceID() {
+		t.Errorf("trace ID did not round-trip: extracted %s, want %s (carrier: %v)", got.TraceID(), want.TraceID(), carrier)
+	}
+
+	if got.SpanID() != want.SpanID() {
+		t.Errorf("span ID did not round-trip: extracted %s, want %s (carrier: %v)", got.SpanID(), want.SpanID(), carrier)
+	}
+}
@@ -0,0 +1,112 @@
+package tracelog
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeTB records the output of a test, running its cleanup functions on demand.
+type fakeTB struct {
+	testing.TB
+
+	mu       sync.Mutex
+	logs     []string
+	failed   bool
+	cleanups []func()
+}
+
+func (tb *fakeTB) Helper() {}
+
+func (tb *fakeTB) Log(args ...interface{}) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	tb.logs = append(tb.logs, fmt.Sprint(args...))
+}
+
+func (tb *fakeTB) Logf(format string, args ...interface{}) {
+	tb.Log(fmt.Sprintf(format, args...))
+}
+
+func (tb *fakeTB) Error(args ...interface{}) {
+	tb.Log(args...)
+
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	tb.failed = true
+}
+
+func (tb *fakeTB) Failed() bool {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	return tb.failed
+}
+
+func (tb *fakeTB) Cleanup(f func()) {
+	tb.cleanups = append(tb.cleanups, f)
+}
+
+// cleanup runs the cleanup functions in the reverse order they were registered.
+func (tb *fakeTB) cleanup() {
+	for i := len(tb.cleanups) - 1; i >= 0; i-- {
+		tb.cleanups[i]()
+	}
+}
+
+func (tb *fakeTB) output() string {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+
+	return strings.Join(tb.logs, "\n")
+}
+
+func TestTestingLoggerDumpsSpanEntriesAndEvents(t *testing.T) {
+	useSpanRecorder(t)
+
+	tb := &fakeTB{TB: t}
+	tl := NewTestingLogger(tb)
+
+	lg, span := tl.SetContext(context.Background()).StartSpan("op")
+	lg.Event("cache miss")
+	lg.Error("failed")
+	span.End()
+
+	tb.cleanup()
+
+	out := tb.output()
+	for _, want := range []string{"entries:", `"failed"`, "events:", "cache miss"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output does not contain %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestTestingLoggerFatalStopsGoroutine(t *testing.T) {
+	tb := &fakeTB{TB: t}
+	tl := NewTestingLogger(tb)
+
+	reached := false
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		tl.Fatal("fatal")
+		reached = true
+	}()
+
+	<-done
+
+	if reached {
+		t.Error("Fatal returned, want the goroutine stopped")
+	}
+
+	if !tb.Failed() {
+		t.Error("Fatal did not fail the test")
+	}
+}
@@ -0,0 +1,44 @@
+package tracelog
+
+import (
+	"context"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.uber.org/zap"
+)
+
+// TestUnsampledInfoAllocations checks that logging a field within an unsampled span
+// makes no allocations. The only allocation of the call is made by the caller, boxing
+// the field into the `interface{}` argument, so the field is boxed outside the
+// measured call as on hot paths which reuse their fields.
+func TestUnsampledInfoAllocations(t *testing.T) {
+	if raceEnabled {
+		t.Skip("the race detector adds allocations")
+	}
+
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSampler(sdktrace.NeverSample()))
+	ctx, span := tp.Tracer("test").Start(context.Background(), "unsampled")
+	defer span.End()
+
+	lg := newDiscardLogger().SetContext(ctx)
+
+	var field interface{} = zap.String("k", "v")
+	if got := testing.AllocsPerRun(100, func() {
+		lg.Info("msg", field)
+	}); got != 0 {
+		t.Errorf("got %v allocations, want 0", got)
+	}
+
+	var sink interface{}
+	boxing := testing.AllocsPerRun(100, func() {
+		sink = zap.String("k", "v")
+	})
+	_ = sink
+
+	if got := testing.AllocsPerRun(100, func() {
+		lg.Info("msg", zap.String("k", "v"))
+	}); got > boxing {
+		t.Errorf("got %v allocations, want at most the %v of boxing the field", got, boxing)
+	}
+}
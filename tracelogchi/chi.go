@@ -0,0 +1,40 @@
+// Package tracelogchi integrates tracelog with the go-chi router, naming server spans
+// after the matched route pattern.
+package tracelogchi
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ninnemana/tracelog"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ChiMiddleware starts a server span for each request and adds an associated logger to
+// the request's `context.Context`, as tracelog.Middleware does. Once the request has been
+// routed, the span is named after the matched route pattern, such as `/users/{id}`, and
+// tagged with the `http.route` attribute, giving low-cardinality span names. The route
+// pattern is also added to the completion entry as the `route` field. The span keeps its
+// default name when no route pattern is available.
+func ChiMiddleware(tl *tracelog.TraceLogger, opts ...tracelog.MiddlewareOption) func(http.Handler) http.Handler {
+	mw := tracelog.Middleware(tl, opts...)
+
+	return func(next http.Handler) http.Handler {
+		return mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r)
+
+			rctx := chi.RouteContext(r.Context())
+			if rctx == nil {
+				return
+			}
+
+			pattern := rctx.RoutePattern()
+			if pattern == "" {
+				return
+			}
+
+			trace.SpanFromContext(r.Context()).SetName(pattern)
+			tl.TagRoute(r.Context(), pattern)
+		}))
+	}
+}
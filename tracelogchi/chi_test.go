@@ -0,0 +1,92 @@
+package tracelogchi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/ninnemana/tracelog"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// serve routes a GET request for target through a chi router using ChiMiddleware,
+// returning the ended spans and the logged entries.
+func serve(t *testing.T, target string) ([]sdktrace.ReadOnlySpan, []observer.LoggedEntry) {
+	t.Helper()
+
+	rec := tracetest.NewSpanRecorder()
+	prev := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(rec)))
+	t.Cleanup(func() {
+		otel.SetTracerProvider(prev)
+	})
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	tl := tracelog.NewLogger(tracelog.WithLogger(zap.New(core)))
+
+	r := chi.NewRouter()
+	r.Use(ChiMiddleware(tl))
+	r.Get("/users/{id}", func(http.ResponseWriter, *http.Request) {})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, target, nil))
+
+	return rec.Ended(), logs.All()
+}
+
+func TestChiMiddlewareRecordsRoutePattern(t *testing.T) {
+	spans, entries := serve(t, "/users/42")
+
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+
+	if got := spans[0].Name(); got != "/users/{id}" {
+		t.Errorf("span name = %q, want the route pattern", got)
+	}
+
+	var route string
+	for _, attr := range spans[0].Attributes() {
+		if attr.Key == semconv.HTTPRouteKey {
+			route = attr.Value.AsString()
+		}
+	}
+
+	if route != "/users/{id}" {
+		t.Errorf("http.route = %q, want the route pattern", route)
+	}
+
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want the completion entry", len(entries))
+	}
+
+	if got := entries[0].ContextMap()["route"]; got != "/users/{id}" {
+		t.Errorf("route field = %v, want the route pattern", got)
+	}
+}
+
+func TestChiMiddlewareKeepsNameWithoutRoute(t *testing.T) {
+	spans, entries := serve(t, "/missing")
+
+	if len(spans) != 1 {
+		t.Fatalf("got %d spans, want 1", len(spans))
+	}
+
+	if got := spans[0].Name(); got != "HTTP GET" {
+		t.Errorf("span name = %q, want the default name", got)
+	}
+
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want the completion entry", len(entries))
+	}
+
+	if _, ok := entries[0].ContextMap()["route"]; ok {
+		t.Error("completion entry has a route without a matched route")
+	}
+}
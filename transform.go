@@ -0,0 +1,117 @@
+package tracelog
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// A FieldTransformer modifies an entry and its fields before they are written. The
+// fields include those added using With, so the correlation fields can be transformed.
+type FieldTransformer func(entry zapcore.Entry, fields []zapcore.Field) (zapcore.Entry, []zapcore.Field)
+
+// WithFieldTransformers applies the transformers, in order, to every entry before it is
+// written. See NewFieldTransformerCore.
+func WithFieldTransformers(transformers ...FieldTransformer) LoggerOption {
+	return func(tl *TraceLogger) {
+		if tl == nil || len(transformers) == 0 {
+			return
+		}
+
+		tl.zapOpts = append(tl.zapOpts, zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return NewFieldTransformerCore(core, transformers...)
+		}))
+	}
+}
+
+// NewFieldTransformerCore creates a `zapcore.Core` that applies the transformers, in
+// order, to each entry and its fields before writing them to the underlying core.
+// Fields added using With are held by the core until the entry is written, so they are
+// encoded on every write rather than once. Keys written by the encoder, such as the
+// message key, are not fields; rename them using WithEncoderConfig. The `audit` and
+// `timestamp` fields of AuditLogger entries are not passed to the transformers.
+func NewFieldTransformerCore(underlying zapcore.Core, transformers ...FieldTransformer) zapcore.Core {
+	return &transformCore{
+		Core:         underlying,
+		transformers: transformers,
+	}
+}
+
+// transformCore applies FieldTransformers to entries written to the wrapped core.
+type transformCore struct {
+	zapcore.Core
+	fields       []zapcore.Field
+	transformers []FieldTransformer
+}
+
+func (c *transformCore) With(fields []zapcore.Field) zapcore.Core {
+	clone := *c
+	clone.fields = make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	clone.fields = append(clone.fields, c.fields...)
+	clone.fields = append(clone.fields, fields...)
+
+	return &clone
+}
+
+func (c *transformCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+
+	return ce
+}
+
+func (c *transformCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	all := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	all = append(all, c.fields...)
+	all = append(all, fields...)
+
+	// The stamp of AuditLogger entries is never transformed.
+	stamp, all, audit := withoutAuditStamp(all)
+
+	for _, t := range c.transformers {
+		ent, all = t(ent, all)
+	}
+
+	if audit {
+		// The stamp precedes any namespace opened by the fields.
+		all = append([]zapcore.Field{stamp}, all...)
+	}
+
+	return c.Core.Write(ent, all)
+}
+
+// RenameField renames the fields with the key from to the key to.
+func RenameField(from, to string) FieldTransformer {
+	return func(ent zapcore.Entry, fields []zapcore.Field) (zapcore.Entry, []zapcore.Field) {
+		for i := range fields {
+			if fields[i].Key == from {
+				fields[i].Key = to
+			}
+		}
+
+		return ent, fields
+	}
+}
+
+// RemoveField removes the fields with the provided key.
+func RemoveField(key string) FieldTransformer {
+	return func(ent zapcore.Entry, fields []zapcore.Field) (zapcore.Entry, []zapcore.Field) {
+		kept := fields[:0]
+		for _, f := range fields {
+			if f.Key != key {
+				kept = append(kept, f)
+			}
+		}
+
+		return ent, kept
+	}
+}
+
+// AddStaticField adds a field with the provided key and value, encoded using `zap.Any`.
+func AddStaticField(key string, val interface{}) FieldTransformer {
+	field := zap.Any(key, val)
+
+	return func(ent zapcore.Entry, fields []zapcore.Field) (zapcore.Entry, []zapcore.Field) {
+		return ent, append(fields, field)
+	}
+}
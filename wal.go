@@ -0,0 +1,361 @@
+package tracelog
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	walFileName       = "tracelog.wal"
+	walCommitFileName = "tracelog.wal.commit"
+
+	// walHeaderSize is the size of the big-endian length and CRC-32C checksum
+	// preceding each entry.
+	walHeaderSize = 8
+
+	// walCommitInterval is the number of delivered entries after which the delivered
+	// offset is committed, bounding the entries delivered again after a crash.
+	walCommitInterval = 64
+
+	// walTruncateSize is the size from which the WAL is emptied once all of its
+	// entries have been delivered.
+	walTruncateSize = 1 << 20
+)
+
+// walCRCTable is used to checksum WAL entries.
+var walCRCTable = crc32.MakeTable(crc32.Castagnoli)
+
+// walWriteSyncer persists entries to a write-ahead log before forwarding them, so
+// entries which were not delivered can be replayed after a crash.
+type walWriteSyncer struct {
+	dir        string
+	underlying zapcore.WriteSyncer
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+
+	// delivered is the offset up to which entries have been accepted by underlying,
+	// and committed the offset last recorded in the commit file.
+	delivered int64
+	committed int64
+	pending   int
+}
+
+// NewWALWriteSyncer creates a `zapcore.WriteSyncer` that appends each entry to a
+// write-ahead log in walDir, syncing it to disk before forwarding the entry to
+// underlying. Entries which were not delivered before the WAL was last closed are
+// replayed first, and entries which underlying rejects are retried on the next write.
+// The delivered offset is committed every 64 entries and on Sync and Close, so after
+// a crash up to 64 entries may be delivered again. Entries failing their checksum,
+// such as one partially written during a crash, are discarded along with the rest of
+// the WAL. The returned WriteSyncer implements `io.Closer`.
+func NewWALWriteSyncer(walDir string, underlying zapcore.WriteSyncer) (zapcore.WriteSyncer, error) {
+	ws, err := openWAL(walDir, underlying)
+	if err != nil {
+		return nil, err
+	}
+
+	ws.mu.Lock()
+	err = ws.deliver()
+	ws.mu.Unlock()
+
+	if err != nil {
+		ws.file.Close()
+
+		return nil, fmt.Errorf("failed to replay WAL: %w", err)
+	}
+
+	return ws, nil
+}
+
+// RecoverWAL replays the entries in the write-ahead log in walDir which were not
+// delivered, writing them to underlying.
+func RecoverWAL(walDir string, underlying zapcore.WriteSyncer) error {
+	ws, err := openWAL(walDir, underlying)
+	if err != nil {
+		return err
+	}
+
+	ws.mu.Lock()
+	err = ws.deliver()
+	ws.mu.Unlock()
+
+	if closeErr := ws.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return underlying.Sync()
+}
+
+func openWAL(dir string, underlying zapcore.WriteSyncer) (*walWriteSyncer, error) {
+	if err := os.MkdirAll(dir, 0o750); err != nil {
+		return nil, fmt.Errorf("failed to create WAL directory: %w", err)
+	}
+
+	file, err := os.OpenFile(filepath.Join(dir, walFileName), os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open WAL: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+
+		return nil, fmt.Errorf("failed to stat WAL: %w", err)
+	}
+
+	committed, err := readWALCommit(dir)
+	if err != nil {
+		file.Close()
+
+		return nil, err
+	}
+
+	// The WAL may have been truncated before the commit was reset.
+	if committed > info.Size() {
+		committed = info.Size()
+	}
+
+	return &walWriteSyncer{
+		dir:        dir,
+		underlying: underlying,
+		file:       file,
+		size:       info.Size(),
+		delivered:  committed,
+		committed:  committed,
+	}, nil
+}
+
+func (ws *walWriteSyncer) Write(p []byte) (int, error) {
+	frame := make([]byte, walHeaderSize+len(p))
+	binary.BigEndian.PutUint32(frame, uint32(len(p)))
+	binary.BigEndian.PutUint32(frame[4:], crc32.Checksum(p, walCRCTable))
+	copy(frame[walHeaderSize:], p)
+
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if _, err := ws.file.WriteAt(frame, ws.size); err != nil {
+		return 0, fmt.Errorf("failed to append to WAL: %w", err)
+	}
+
+	if err := ws.file.Sync(); err != nil {
+		return 0, fmt.Errorf("failed to sync WAL: %w", err)
+	}
+
+	ws.size += int64(len(frame))
+
+	if err := ws.deliver(); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// deliver writes the undelivered entries to the underlying WriteSyncer, committing the
+// delivered offset in batches. The WAL is truncated once every entry is delivered and
+// it has grown past walTruncateSize.
+func (ws *walWriteSyncer) deliver() error {
+	var header [walHeaderSize]byte
+
+	for ws.delivered < ws.size {
+		if ws.size-ws.delivered < walHeaderSize {
+			return ws.discard()
+		}
+
+		if _, err := ws.file.ReadAt(header[:], ws.delivered); err != nil {
+			return fmt.Errorf("failed to read WAL entry header: %w", err)
+		}
+
+		n := int64(binary.BigEndian.Uint32(header[:]))
+		if n > ws.size-ws.delivered-walHeaderSize {
+			// A partially written entry from a crash can't be delivered.
+			return ws.discard()
+		}
+
+		entry := make([]byte, n)
+		if _, err := ws.file.ReadAt(entry, ws.delivered+walHeaderSize); err != nil {
+			return fmt.Errorf("failed to read WAL entry: %w", err)
+		}
+
+		if crc32.Checksum(entry, walCRCTable) != binary.BigEndian.Uint32(header[4:]) {
+			return ws.discard()
+		}
+
+		if _, err := ws.underlying.Write(entry); err != nil {
+			return fmt.Errorf("failed to deliver WAL entry: %w", err)
+		}
+
+		ws.delivered += walHeaderSize + n
+		ws.pending++
+
+		if ws.pending >= walCommitInterval {
+			if err := ws.commit(ws.delivered); err != nil {
+				return err
+			}
+		}
+	}
+
+	if ws.size < walTruncateSize {
+		return nil
+	}
+
+	return ws.truncate()
+}
+
+// discard drops the undeliverable entries following the delivered offset.
+func (ws *walWriteSyncer) discard() error {
+	if err := ws.file.Truncate(ws.delivered); err != nil {
+		return fmt.Errorf("failed to discard corrupt WAL entries: %w", err)
+	}
+
+	if err := ws.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync WAL: %w", err)
+	}
+
+	ws.size = ws.delivered
+
+	return nil
+}
+
+// truncate empties the WAL once its entries have been delivered.
+func (ws *walWriteSyncer) truncate() error {
+	if err := ws.file.Truncate(0); err != nil {
+		return fmt.Errorf("failed to truncate WAL: %w", err)
+	}
+
+	if err := ws.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync WAL: %w", err)
+	}
+
+	ws.size, ws.delivered = 0, 0
+
+	return ws.commit(0)
+}
+
+// commit atomically records the offset up to which entries have been delivered.
+func (ws *walWriteSyncer) commit(offset int64) error {
+	tmp, err := os.CreateTemp(ws.dir, walCommitFileName+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create WAL commit: %w", err)
+	}
+
+	defer os.Remove(tmp.Name())
+
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(offset))
+
+	if _, err := tmp.Write(buf[:]); err != nil {
+		tmp.Close()
+
+		return fmt.Errorf("failed to write WAL commit: %w", err)
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+
+		return fmt.Errorf("failed to sync WAL commit: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close WAL commit: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), filepath.Join(ws.dir, walCommitFileName)); err != nil {
+		return fmt.Errorf("failed to replace WAL commit: %w", err)
+	}
+
+	// The rename is only durable once the directory has been synced.
+	if err := syncDir(ws.dir); err != nil {
+		return err
+	}
+
+	ws.committed, ws.pending = offset, 0
+
+	return nil
+}
+
+// Sync commits the offset of the delivered entries, then syncs the underlying
+// WriteSyncer.
+func (ws *walWriteSyncer) Sync() error {
+	ws.mu.Lock()
+	err := ws.commitDelivered()
+	ws.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	return ws.underlying.Sync()
+}
+
+// Close commits the offset of the delivered entries and closes the WAL.
+func (ws *walWriteSyncer) Close() error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	err := ws.commitDelivered()
+	if closeErr := ws.file.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// commitDelivered commits the delivered offset when it has not yet been committed.
+func (ws *walWriteSyncer) commitDelivered() error {
+	if ws.delivered == ws.committed {
+		return nil
+	}
+
+	return ws.commit(ws.delivered)
+}
+
+// syncDir syncs the directory entries of dir to disk.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("failed to open WAL directory: %w", err)
+	}
+
+	err = d.Sync()
+	if closeErr := d.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to sync WAL directory: %w", err)
+	}
+
+	return nil
+}
+
+// readWALCommit reads the offset up to which entries have been delivered.
+func readWALCommit(dir string) (int64, error) {
+	buf, err := os.ReadFile(filepath.Join(dir, walCommitFileName))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to read WAL commit: %w", err)
+	}
+
+	if len(buf) != 8 {
+		return 0, fmt.Errorf("invalid WAL commit of %d bytes", len(buf))
+	}
+
+	return int64(binary.BigEndian.Uint64(buf)), nil
+}
@@ -0,0 +1,166 @@
+package tracelog
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+// recordingWriteSyncer records the entries written to it, failing writes while failing
+// is set.
+type recordingWriteSyncer struct {
+	entries []string
+	failing bool
+}
+
+func (ws *recordingWriteSyncer) Write(p []byte) (int, error) {
+	if ws.failing {
+		return 0, errors.New("unavailable")
+	}
+
+	ws.entries = append(ws.entries, string(p))
+
+	return len(p), nil
+}
+
+func (ws *recordingWriteSyncer) Sync() error {
+	return nil
+}
+
+func TestWALDeliversEntries(t *testing.T) {
+	dir := t.TempDir()
+	out := &recordingWriteSyncer{}
+
+	ws, err := NewWALWriteSyncer(dir, out)
+	if err != nil {
+		t.Fatalf("failed to open WAL: %v", err)
+	}
+
+	for i := 0; i < walCommitInterval+2; i++ {
+		if _, err := ws.Write([]byte(strconv.Itoa(i))); err != nil {
+			t.Fatalf("failed to write entry: %v", err)
+		}
+	}
+
+	if err := ws.(io.Closer).Close(); err != nil {
+		t.Fatalf("failed to close WAL: %v", err)
+	}
+
+	if n := len(out.entries); n != walCommitInterval+2 {
+		t.Fatalf("delivered %d entries, want %d", n, walCommitInterval+2)
+	}
+
+	// Delivered entries are committed on Close, so reopening does not replay them.
+	replayed := &recordingWriteSyncer{}
+	if err := RecoverWAL(dir, replayed); err != nil {
+		t.Fatalf("failed to recover WAL: %v", err)
+	}
+
+	if len(replayed.entries) != 0 {
+		t.Errorf("replayed delivered entries %v", replayed.entries)
+	}
+}
+
+func TestWALReplaysPendingEntriesOnOpen(t *testing.T) {
+	dir := t.TempDir()
+	out := &recordingWriteSyncer{failing: true}
+
+	ws, err := NewWALWriteSyncer(dir, out)
+	if err != nil {
+		t.Fatalf("failed to open WAL: %v", err)
+	}
+
+	for _, entry := range []string{"first", "second"} {
+		if _, err := ws.Write([]byte(entry)); err == nil {
+			t.Fatal("expected an error delivering to an unavailable WriteSyncer")
+		}
+	}
+
+	if err := ws.(io.Closer).Close(); err != nil {
+		t.Fatalf("failed to close WAL: %v", err)
+	}
+
+	replayed := &recordingWriteSyncer{}
+	ws, err = NewWALWriteSyncer(dir, replayed)
+	if err != nil {
+		t.Fatalf("failed to reopen WAL: %v", err)
+	}
+	defer ws.(io.Closer).Close()
+
+	if want := []string{"first", "second"}; !reflect.DeepEqual(replayed.entries, want) {
+		t.Errorf("replayed %v, want %v", replayed.entries, want)
+	}
+}
+
+func TestWALDiscardsCorruptEntries(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(t *testing.T, path string)
+	}{
+		{
+			name: "checksum mismatch",
+			corrupt: func(t *testing.T, path string) {
+				f, err := os.OpenFile(path, os.O_RDWR, 0)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer f.Close()
+
+				info, err := f.Stat()
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				// Flip the last byte of the second entry.
+				if _, err := f.WriteAt([]byte{'X'}, info.Size()-1); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "partial entry",
+			corrupt: func(t *testing.T, path string) {
+				info, err := os.Stat(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				if err := os.Truncate(path, info.Size()-2); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+
+			ws, err := NewWALWriteSyncer(dir, &recordingWriteSyncer{failing: true})
+			if err != nil {
+				t.Fatalf("failed to open WAL: %v", err)
+			}
+
+			_, _ = ws.Write([]byte("first"))
+			_, _ = ws.Write([]byte("second"))
+			ws.(io.Closer).Close()
+
+			tt.corrupt(t, filepath.Join(dir, walFileName))
+
+			replayed := &recordingWriteSyncer{}
+			if err := RecoverWAL(dir, replayed); err != nil {
+				t.Fatalf("failed to recover WAL: %v", err)
+			}
+
+			if want := []string{"first"}; !reflect.DeepEqual(replayed.entries, want) {
+				t.Errorf("replayed %v, want %v", replayed.entries, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package tracelog
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/propagation"
+	"go.uber.org/zap"
+)
+
+// NewWorkerLogger creates a TraceLogger for a pool of workers consuming jobs which carry
+// serialized trace context, writing to the provided base logger. Use ForJob to bind a
+// logger to each job.
+func NewWorkerLogger(base *zap.Logger, opts ...LoggerOption) *TraceLogger {
+	return NewLogger(append([]LoggerOption{WithLogger(base)}, opts...)...)
+}
+
+// ForJob extracts the trace context of a job from the carrier using the configured
+// propagator, returning a logger bound to the job's span along with a `context.Context`
+// in which the span is current and which carries the logger, for passing to the job.
+//
+//	lg, ctx := workers.ForJob(propagation.HeaderCarrier(job.Headers))
+//	err := job.Run(ctx)
+func (tl *TraceLogger) ForJob(carrier propagation.TextMapCarrier) (*TraceLogger, context.Context) {
+	ctx := tl.Context()
+	if p := tl.textMapPropagator(); p != nil && carrier != nil {
+		ctx = p.Extract(ctx, carrier)
+	}
+
+	// Rebind replaces the correlation fields of a worker logger already bound to a job.
+	lg := tl.Rebind(ctx)
+
+	return lg, NewContext(ctx, lg)
+}
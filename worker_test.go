@@ -0,0 +1,34 @@
+package tracelog
+
+import (
+	"net/http"
+	"testing"
+
+	"go.opentelemetry.io/otel/propagation"
+)
+
+func TestForJobReplacesCorrelationFields(t *testing.T) {
+	tl, buf := newBufferedLogger(WithPropagator(propagation.TraceContext{}))
+
+	carrier := propagation.HeaderCarrier(http.Header{})
+	propagation.TraceContext{}.Inject(contextWithSpan(2, 2), carrier)
+
+	// A logger already bound to one job is reused for the next.
+	first, _ := tl.ForJob(propagation.HeaderCarrier(http.Header{}))
+	first = first.SetContext(contextWithSpan(1, 1))
+
+	lg, ctx := first.ForJob(carrier)
+	lg.Info("job")
+
+	if FromContext(ctx) != lg {
+		t.Error("context does not carry the job logger")
+	}
+
+	lines := buf.Lines()
+	assertUniqueKeys(t, lines[len(lines)-1], correlationKeys...)
+
+	entries := buf.Entries(t)
+	if got, want := entries[len(entries)-1]["traceID"], spanContext(2, 2).TraceID().String(); got != want {
+		t.Errorf("traceID = %v, want %s", got, want)
+	}
+}
@@ -0,0 +1,67 @@
+package tracelog
+
+import (
+	"context"
+	"errors"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// errorSpanField is the field carrying the span context of an error wrapped using
+// WrapError.
+const errorSpanField = "errorSpan"
+
+// spanContextError associates an error with the span context it occurred in.
+type spanContextError struct {
+	err     error
+	spanCtx trace.SpanContext
+}
+
+// WrapError wraps err with the span context found in ctx, so error tracking systems can
+// correlate the error with its trace. The wrapped error implements Unwrap, and exposes
+// the span context using a `SpanContext() trace.SpanContext` method. When logged using
+// the default error formatting, the span context is added as the `errorSpan` field. A
+// nil error is returned as nil.
+func WrapError(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return &spanContextError{
+		err:     err,
+		spanCtx: trace.SpanContextFromContext(ctx),
+	}
+}
+
+func (e *spanContextError) Error() string {
+	return e.err.Error()
+}
+
+func (e *spanContextError) Unwrap() error {
+	return e.err
+}
+
+// SpanContext returns the span context the error was wrapped with.
+func (e *spanContextError) SpanContext() trace.SpanContext {
+	return e.spanCtx
+}
+
+// errorSpanFields returns the span context of an error wrapped using WrapError as the
+// `errorSpan` field, when it is valid.
+func errorSpanFields(err error) []zap.Field {
+	var sce interface {
+		SpanContext() trace.SpanContext
+	}
+
+	if !errors.As(err, &sce) {
+		return nil
+	}
+
+	spanCtx := sce.SpanContext()
+	if !spanCtx.IsValid() {
+		return nil
+	}
+
+	return []zap.Field{zap.Object(errorSpanField, spanContextMarshaler(spanCtx))}
+}